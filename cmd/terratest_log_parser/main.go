@@ -0,0 +1,79 @@
+// terratest_log_parser reads the output of go test -v, which interleaves the logs of every test that runs in parallel,
+// and writes the logs of each test to its own file, along with a JUnit XML report and a summary of every test. For
+// example:
+//
+//	go test -v -timeout 90m ./test/... | tee test_output.log
+//	terratest_log_parser --testlog test_output.log --outputdir test_output
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/terratest/modules/logger/parser"
+)
+
+const (
+	junitReportFileName = "report.xml"
+	summaryFileName     = "summary.log"
+)
+
+func main() {
+	testLog := flag.String("testlog", "", "The path to the output of go test -v to parse. Defaults to stdin.")
+	outputDir := flag.String("outputdir", "", "The folder to write the per test logs, the JUnit XML report, and the summary to. Required.")
+	flag.Parse()
+
+	if *outputDir == "" {
+		fmt.Fprintln(os.Stderr, "The --outputdir flag is required.")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(*testLog, *outputDir); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(testLog string, outputDir string) error {
+	var input io.Reader = os.Stdin
+	if testLog != "" {
+		file, err := os.Open(testLog)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	report, err := parser.Parse(input)
+	if err != nil {
+		return err
+	}
+
+	if err := report.WriteTestLogs(outputDir); err != nil {
+		return err
+	}
+
+	junitReport, err := os.Create(filepath.Join(outputDir, junitReportFileName))
+	if err != nil {
+		return err
+	}
+	defer junitReport.Close()
+
+	if err := report.WriteJUnitXML(junitReport); err != nil {
+		return err
+	}
+
+	summary := report.Summary()
+	if err := ioutil.WriteFile(filepath.Join(outputDir, summaryFileName), []byte(summary), 0644); err != nil {
+		return err
+	}
+
+	fmt.Print(summary)
+	return nil
+}
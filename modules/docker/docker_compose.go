@@ -1,15 +1,23 @@
 package docker
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/shell"
 )
 
 // Options are Docker options.
 type Options struct {
-	WorkingDir string
-	EnvVars    map[string]string
+	WorkingDir         string
+	EnvVars            map[string]string
+	RetryableErrors    map[string]string // If docker-compose fails with one of these (transient) errors, retry. The keys are regular expressions (escape plain text with regexp.QuoteMeta) to look for in the output and error and the message is what to display to a user if that error is found.
+	MaxRetries         int               // Maximum number of times to retry errors matching RetryableErrors
+	TimeBetweenRetries time.Duration     // The amount of time to wait between retries
+	Logger             logger.Logger     `json:"-"` // The Logger to log docker-compose and its output with. Defaults to the default Logger of the logger package.
 }
 
 // RunDockerCompose runs docker-compose with the given arguments and options and return stdout/stderr.
@@ -21,7 +29,8 @@ func RunDockerCompose(t *testing.T, options *Options, args ...string) string {
 	return out
 }
 
-// RunDockerComposeE runs docker-compose with the given arguments and options and return stdout/stderr.
+// RunDockerComposeE runs docker-compose with the given arguments and options and return stdout/stderr. If docker-compose
+// fails with one of the RetryableErrors, it's retried up to MaxRetries times.
 func RunDockerComposeE(t *testing.T, options *Options, args ...string) (string, error) {
 	cmd := shell.Command{
 		Command: "docker-compose",
@@ -30,7 +39,13 @@ func RunDockerComposeE(t *testing.T, options *Options, args ...string) (string,
 		Args:       append([]string{"--project-name", t.Name()}, args...),
 		WorkingDir: options.WorkingDir,
 		Env:        options.EnvVars,
+		Logger:     options.Logger,
+		LogModule:  "docker",
 	}
 
-	return shell.RunCommandAndGetOutputE(t, cmd)
+	retryOptions := retry.Options{MaxRetries: options.MaxRetries, Backoff: retry.ConstantBackoff{Sleep: options.TimeBetweenRetries}, Logger: options.Logger}
+	description := fmt.Sprintf("Running docker-compose %v", cmd.Args)
+	return retry.DoWithRetryableErrorsAndOptionsE(t, description, options.RetryableErrors, retryOptions, func() (string, error) {
+		return shell.RunCommandAndGetOutputE(t, cmd)
+	})
 }
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunDockerComposeRetriesOnRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "docker-compose",
+		shell.StubResponse{Stderr: "ERROR: error pulling image configuration: TLS handshake timeout\n", ExitCode: 1},
+		shell.StubResponse{Stdout: "Creating network\n"},
+	)
+
+	options := &Options{
+		EnvVars:            stubs.EnvVars(),
+		RetryableErrors:    map[string]string{"TLS handshake timeout": "Docker Hub is flaky"},
+		MaxRetries:         2,
+		TimeBetweenRetries: time.Millisecond,
+	}
+
+	out, err := RunDockerComposeE(t, options, "up", "-d")
+	assert.NoError(t, err)
+	assert.Equal(t, "Creating network", out)
+
+	invocations := stubs.Invocations(t, "docker-compose")
+	assert.Len(t, invocations, 2)
+	assert.Equal(t, []string{"--project-name", t.Name(), "up", "-d"}, invocations[0].Args)
+}
+
+func TestRunDockerComposeDoesNotRetryOtherErrors(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "docker-compose", shell.StubResponse{Stderr: "ERROR: no such service: web\n", ExitCode: 1})
+
+	options := &Options{
+		EnvVars:         stubs.EnvVars(),
+		RetryableErrors: map[string]string{"TLS handshake timeout": "Docker Hub is flaky"},
+		MaxRetries:      2,
+	}
+
+	_, err := RunDockerComposeE(t, options, "up", "-d")
+	assert.Error(t, err)
+	assert.Len(t, stubs.Invocations(t, "docker-compose"), 1)
+}
@@ -0,0 +1,163 @@
+package http_helper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+)
+
+// CheckResult is the result of a single check of an AvailabilityMonitor.
+type CheckResult struct {
+	Time             time.Time     // When the check started
+	Latency          time.Duration // How long the HTTP GET took
+	StatusCode       int           // The status code of the response, or -1 if there was no response
+	Err              error         // If the check failed, why: either the error from the HTTP GET or a ValidationFunctionFailed error
+	ValidationFailed bool          // True if there was a response, but the validation function rejected it
+}
+
+// ErrorWindow is a run of consecutive failed checks, such as the time an endpoint was down during a deployment.
+type ErrorWindow struct {
+	Start    time.Time // When the first failed check of the window started
+	End      time.Time // When the last failed check of the window completed
+	Failures int       // How many checks failed in the window
+}
+
+// Duration returns how long the window lasted.
+func (window ErrorWindow) Duration() time.Duration {
+	return window.End.Sub(window.Start)
+}
+
+// AvailabilityReport summarizes the checks of an AvailabilityMonitor.
+type AvailabilityReport struct {
+	URL                string
+	Checks             []CheckResult // Every check, in the order they happened
+	Total              int           // How many checks there were
+	Failures           int           // How many checks failed, for any reason
+	RequestErrors      int           // How many checks failed because the HTTP GET failed (e.g. connection refused or timeout)
+	ValidationFailures int           // How many checks failed because the validation function rejected the response
+	ErrorWindows       []ErrorWindow // Every run of consecutive failed checks, in the order they happened
+	LatencyP50         time.Duration // The median latency of the checks that got a response
+	LatencyP90         time.Duration // The 90th percentile latency of the checks that got a response
+	LatencyP99         time.Duration // The 99th percentile latency of the checks that got a response
+	LatencyMax         time.Duration // The maximum latency of the checks that got a response
+}
+
+func (report AvailabilityReport) String() string {
+	return fmt.Sprintf("%d checks of URL %s: %d failed (%d request errors, %d validation failures) in %d error windows. Latency p50: %s, p90: %s, p99: %s, max: %s.",
+		report.Total, report.URL, report.Failures, report.RequestErrors, report.ValidationFailures, len(report.ErrorWindows),
+		report.LatencyP50, report.LatencyP90, report.LatencyP99, report.LatencyMax)
+}
+
+// AvailabilityMonitor checks a URL in the background over and over, and records the result of every check. Create one
+// with ContinuouslyCheck.
+type AvailabilityMonitor struct {
+	t      *testing.T
+	url    string
+	runner *retry.BackgroundRunner
+}
+
+// ContinuouslyCheck performs an HTTP GET on the given URL in the background every interval, and validates the
+// returned status code and body using the given function, until Stop is called on the returned monitor. The interval
+// is measured from the start of one check to the start of the next, so slow responses don't make the checks less
+// frequent, unless a check takes longer than the interval, in which case the next check starts as soon as it completes. Unlike
+// running HttpGetWithCustomValidation in the background, a failed check doesn't fail the test. Instead, every check
+// is recorded, and Stop returns a report of all of them, so the test can decide how many failures it can tolerate.
+// This is handy for checking that a redeploy has zero downtime.
+func ContinuouslyCheck(t *testing.T, url string, validateResponse func(int, string) bool, interval time.Duration) *AvailabilityMonitor {
+	runner := retry.RunInBackgroundEvery(t, fmt.Sprintf("Check URL %s", url), interval, func() (interface{}, error) {
+		start := time.Now()
+		statusCode, body, err := HttpGetE(t, url)
+		result := CheckResult{Time: start, Latency: time.Since(start), StatusCode: statusCode, Err: err}
+
+		if err == nil && !validateResponse(statusCode, body) {
+			result.Err = ValidationFunctionFailed{Url: url, Status: statusCode, Body: body}
+			result.ValidationFailed = true
+		}
+		return result, result.Err
+	})
+
+	return &AvailabilityMonitor{t: t, url: url, runner: runner}
+}
+
+// Report returns a report of the checks so far, without stopping the monitor.
+func (monitor *AvailabilityMonitor) Report() AvailabilityReport {
+	checks := []CheckResult{}
+	for _, result := range monitor.runner.Results() {
+		if check, ok := result.Output.(CheckResult); ok {
+			checks = append(checks, check)
+		}
+	}
+	return newAvailabilityReport(monitor.url, checks)
+}
+
+// Stop stops checking the URL, waiting for the check in progress, if any, to complete, and returns a report of all the
+// checks. It's safe to call Stop more than once.
+func (monitor *AvailabilityMonitor) Stop() AvailabilityReport {
+	monitor.runner.Stop()
+
+	report := monitor.Report()
+	logger.Log(monitor.t, report.String())
+	return report
+}
+
+// Summarize the given checks of the given URL
+func newAvailabilityReport(url string, checks []CheckResult) AvailabilityReport {
+	report := AvailabilityReport{URL: url, Checks: checks, Total: len(checks)}
+	latencies := []time.Duration{}
+
+	var window *ErrorWindow
+	for _, check := range checks {
+		if check.Err == nil || check.ValidationFailed {
+			latencies = append(latencies, check.Latency)
+		}
+
+		if check.Err == nil {
+			if window != nil {
+				report.ErrorWindows = append(report.ErrorWindows, *window)
+				window = nil
+			}
+			continue
+		}
+
+		report.Failures++
+		if check.ValidationFailed {
+			report.ValidationFailures++
+		} else {
+			report.RequestErrors++
+		}
+
+		if window == nil {
+			window = &ErrorWindow{Start: check.Time}
+		}
+		window.End = check.Time.Add(check.Latency)
+		window.Failures++
+	}
+	if window != nil {
+		report.ErrorWindows = append(report.ErrorWindows, *window)
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.LatencyP50 = percentile(latencies, 50)
+	report.LatencyP90 = percentile(latencies, 90)
+	report.LatencyP99 = percentile(latencies, 99)
+	report.LatencyMax = percentile(latencies, 100)
+
+	return report
+}
+
+// Return the given percentile of the given sorted durations, using the nearest-rank method, or zero if there are none
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
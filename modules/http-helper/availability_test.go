@@ -0,0 +1,89 @@
+package http_helper
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinuouslyCheck(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	var requests int32
+	server.Handle("/", func(w http.ResponseWriter, r *http.Request) {
+		// Requests 3 and 4 fail, as if the server was briefly down during a deployment
+		switch atomic.AddInt32(&requests, 1) {
+		case 3, 4:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	})
+
+	monitor := ContinuouslyCheck(t, server.URL(), func(statusCode int, body string) bool {
+		return statusCode == 200 && body == "ok"
+	}, 10*time.Millisecond)
+
+	for atomic.LoadInt32(&requests) < 6 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	report := monitor.Stop()
+	assert.True(t, report.Total >= 6)
+	assert.Equal(t, 2, report.Failures)
+	assert.Equal(t, 2, report.ValidationFailures)
+	assert.Equal(t, 0, report.RequestErrors)
+	assert.Len(t, report.ErrorWindows, 1)
+	assert.Equal(t, 2, report.ErrorWindows[0].Failures)
+	assert.Equal(t, http.StatusBadGateway, report.Checks[2].StatusCode)
+	assert.True(t, report.LatencyMax >= report.LatencyP50)
+
+	// Stopping again returns the same checks
+	assert.Equal(t, report.Total, monitor.Stop().Total)
+}
+
+func TestNewAvailabilityReport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	check := func(offset int, latency int, err error, validationFailed bool) CheckResult {
+		return CheckResult{
+			Time:             start.Add(time.Duration(offset) * time.Second),
+			Latency:          time.Duration(latency) * time.Millisecond,
+			Err:              err,
+			ValidationFailed: validationFailed,
+		}
+	}
+	requestErr := errors.New("connection refused")
+
+	report := newAvailabilityReport("http://example.com", []CheckResult{
+		check(0, 10, nil, false),
+		check(1, 50, requestErr, false),
+		check(2, 20, nil, false),
+		check(3, 30, requestErr, true),
+		check(4, 40, requestErr, false),
+		check(5, 60, nil, false),
+	})
+
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 3, report.Failures)
+	assert.Equal(t, 2, report.RequestErrors)
+	assert.Equal(t, 1, report.ValidationFailures)
+	assert.Equal(t, []ErrorWindow{
+		{Start: start.Add(1 * time.Second), End: start.Add(1*time.Second + 50*time.Millisecond), Failures: 1},
+		{Start: start.Add(3 * time.Second), End: start.Add(4*time.Second + 40*time.Millisecond), Failures: 2},
+	}, report.ErrorWindows)
+	assert.Equal(t, 1040*time.Millisecond, report.ErrorWindows[1].Duration())
+
+	// Only the checks that got a response count towards latency: 10, 20, 30, and 60ms
+	assert.Equal(t, 20*time.Millisecond, report.LatencyP50)
+	assert.Equal(t, 60*time.Millisecond, report.LatencyP90)
+	assert.Equal(t, 60*time.Millisecond, report.LatencyMax)
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"strconv"
-	"sync/atomic"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
@@ -26,29 +26,137 @@ func RunDummyServer(t *testing.T, text string) (net.Listener, int) {
 // port it's listening on, or an error if something went wrong while trying to start the listener. Make sure to call
 // the Close() method on the Listener when you're done!
 func RunDummyServerE(t *testing.T, text string) (net.Listener, int, error) {
-	port := getNextPort()
+	server, err := NewDummyServerE(t)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, text)
-	})
+	logger.Logf(t, "Dummy HTTP server on port %d will return the text '%s'", server.Port(), text)
+	server.AddRoute("/", DummyResponse{Body: text})
+
+	return server.listener, server.Port(), nil
+}
+
+// DummyResponse is a canned response for a route of a DummyServer.
+type DummyResponse struct {
+	StatusCode int               // The HTTP status code. Defaults to 200.
+	Body       string            // The response body
+	Headers    map[string]string // Headers to set on the response (e.g. Content-Type)
+}
+
+// DummyServer is an HTTP server for tests, with its own routes, that listens on a free port picked by the OS, so any
+// number of them can run in parallel in the same process. Each route either returns a canned response (see AddRoute)
+// or is served by a custom handler (see Handle). Routes match request paths as in http.ServeMux: a route that ends in
+// a slash, such as "/" or "/api/", matches every path that starts with it, and other routes only match their exact
+// path. Requests that don't match any route get a 404. The server records every request it gets, so tests can check
+// what a component called back with (see WaitForRequest). Make sure to call Close when you're done!
+type DummyServer struct {
+	listener net.Listener
+	server   *http.Server
+	routes   map[string]http.Handler
+	lock     sync.RWMutex
+
+	requests     []RecordedRequest // Every request the server has received, in order
+	updated      chan struct{}     // Closed, and replaced with a new channel, every time a request is recorded
+	requestsLock sync.Mutex
+}
 
-	logger.Logf(t, "Starting dummy HTTP server in port %d that will return the text '%s'", port, text)
+// NewDummyServer starts a DummyServer with no routes on a free port. If it can't be started, fail the test.
+func NewDummyServer(t *testing.T) *DummyServer {
+	server, err := NewDummyServerE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
 
-	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+// NewDummyServerE starts a DummyServer with no routes on a free port.
+func NewDummyServerE(t *testing.T) (*DummyServer, error) {
+	listener, err := net.Listen("tcp", ":0")
 	if err != nil {
-		return nil, 0, fmt.Errorf("error listening: %s", err)
+		return nil, fmt.Errorf("error listening: %s", err)
 	}
 
-	go http.Serve(listener, nil)
+	server := &DummyServer{listener: listener, routes: map[string]http.Handler{}, updated: make(chan struct{})}
+	server.server = &http.Server{Handler: server}
+
+	logger.Logf(t, "Starting dummy HTTP server on port %d", server.Port())
+
+	go server.server.Serve(listener)
+
+	return server, nil
+}
+
+// Port returns the port the server is listening on.
+func (server *DummyServer) Port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+// URL returns the URL of the server (e.g. http://localhost:12345), without a trailing slash.
+func (server *DummyServer) URL() string {
+	return fmt.Sprintf("http://localhost:%d", server.Port())
+}
+
+// AddRoute makes the server return the given canned response for requests that match the given path. If the path
+// already has a route, it's replaced, so a test can change what the server returns along the way.
+func (server *DummyServer) AddRoute(path string, response DummyResponse) {
+	server.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range response.Headers {
+			w.Header().Set(key, value)
+		}
+		if response.StatusCode != 0 {
+			w.WriteHeader(response.StatusCode)
+		}
+		fmt.Fprint(w, response.Body)
+	})
+}
+
+// Handle makes the server serve requests that match the given path with the given handler. If the path already has a
+// route, it's replaced.
+func (server *DummyServer) Handle(path string, handler http.HandlerFunc) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.routes[path] = handler
+}
+
+// ServeHTTP records the given request and serves it with the route that matches its path, or a 404 if there is none.
+func (server *DummyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := server.record(r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	return listener, port, err
+	handler := server.route(r.URL.Path)
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	handler.ServeHTTP(w, r)
 }
 
-// DO NOT ACCESS THIS VARIABLE DIRECTLY. See getNextPort() below.
-var testServerPort int32 = 8080
+// Return the handler of the route that matches the given path: the route for that exact path, if there is one, or
+// else the longest route that ends in a slash and is a prefix of the path
+func (server *DummyServer) route(path string) http.Handler {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+
+	if handler, exists := server.routes[path]; exists {
+		return handler
+	}
+
+	var handler http.Handler
+	longest := 0
+	for route, routeHandler := range server.routes {
+		if strings.HasSuffix(route, "/") && strings.HasPrefix(path, route) && len(route) > longest {
+			handler = routeHandler
+			longest = len(route)
+		}
+	}
+	return handler
+}
 
-// Since we run tests in parallel, we need to ensure that each test runs on a different port. This function returns a
-// unique port by atomically incrementing the testServerPort variable.
-func getNextPort() int {
-	return int(atomic.AddInt32(&testServerPort, 1))
+// Close stops the server.
+func (server *DummyServer) Close() error {
+	return server.server.Close()
 }
@@ -3,6 +3,7 @@ package http_helper
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/random"
@@ -26,3 +27,55 @@ func shutDownServer(t *testing.T, listener io.Closer) {
 	err := listener.Close()
 	assert.NoError(t, err)
 }
+
+func TestRunDummyServerMultipleTimes(t *testing.T) {
+	t.Parallel()
+
+	first, firstPort := RunDummyServer(t, "first")
+	defer shutDownServer(t, first)
+
+	second, secondPort := RunDummyServer(t, "second")
+	defer shutDownServer(t, second)
+
+	assert.NotEqual(t, firstPort, secondPort)
+	HttpGetWithValidation(t, fmt.Sprintf("http://localhost:%d", firstPort), 200, "first")
+	HttpGetWithValidation(t, fmt.Sprintf("http://localhost:%d", secondPort), 200, "second")
+}
+
+func TestDummyServerRoutes(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	server.AddRoute("/", DummyResponse{Body: "root"})
+	server.AddRoute("/health", DummyResponse{StatusCode: 503, Body: "unhealthy", Headers: map[string]string{"X-Health": "bad"}})
+	server.AddRoute("/api/", DummyResponse{Body: "api"})
+	server.Handle("/echo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Query().Get("q"))
+	})
+
+	HttpGetWithValidation(t, server.URL()+"/anything", 200, "root")
+	HttpGetWithValidation(t, server.URL()+"/api/users/1", 200, "api")
+	HttpGetWithValidation(t, server.URL()+"/echo?q=foo", 200, "GET foo")
+
+	response := HTTPDo(t, HTTPRequestOptions{URL: server.URL() + "/health"})
+	assert.Equal(t, 503, response.StatusCode)
+	assert.Equal(t, "bad", response.Headers.Get("X-Health"))
+
+	// Replacing a route changes what the server returns from then on
+	server.AddRoute("/health", DummyResponse{Body: "healthy"})
+	HttpGetWithValidation(t, server.URL()+"/health", 200, "healthy")
+}
+
+func TestDummyServerNotFound(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	server.AddRoute("/only", DummyResponse{Body: "only"})
+
+	statusCode, _ := HttpGet(t, server.URL()+"/other")
+	assert.Equal(t, 404, statusCode)
+}
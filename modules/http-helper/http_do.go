@@ -0,0 +1,232 @@
+package http_helper
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+)
+
+// DefaultTimeout is how long to wait for an HTTP request to complete, if the HTTPRequestOptions don't specify a
+// Timeout. By default, Go does not impose a timeout, so an HTTP connection attempt can hang for a LONG time.
+const DefaultTimeout = 10 * time.Second
+
+// HTTPRequestOptions describes an HTTP request to make with HTTPDo.
+type HTTPRequestOptions struct {
+	Method            string            // The HTTP method (e.g. GET, POST, PUT, or DELETE). Defaults to GET.
+	URL               string            // The URL to send the request to
+	Headers           map[string]string // Headers to set on the request (e.g. Content-Type). A Host header overrides the host sent to the server, e.g. to test a virtual host by IP address.
+	Body              []byte            // The body of the request. A byte slice, rather than a reader, so the request can be retried.
+	BasicAuthUsername string            // If set, the request uses basic auth with this username and BasicAuthPassword
+	BasicAuthPassword string            // The password for basic auth. It's masked in the logs, however short it is (see logger.RegisterSecret).
+	BearerToken       string            // If set, the request sets an Authorization: Bearer header with this token. It's masked in the logs, however short it is (see logger.RegisterSecret).
+	Timeout           time.Duration     // How long to wait for the request to complete, including reading the body. Defaults to DefaultTimeout.
+	DisableRedirects  bool              // If true, redirects are not followed, and the redirect response itself is returned
+	MaxRedirects      int               // The maximum number of redirects to follow. Defaults to 10, as in Go's http.Client.
+	TLSConfig         *tls.Config       // If set, the TLS settings for HTTPS requests (e.g. RootCAs for a private CA, Certificates for mTLS, or InsecureSkipVerify)
+}
+
+// HTTPResponse is the response to a request made with HTTPDo.
+type HTTPResponse struct {
+	StatusCode int         // The HTTP status code
+	Headers    http.Header // The response headers
+	Body       []byte      // The raw response body, exactly as the server sent it
+}
+
+// HTTPDo makes the HTTP request described by the given options and returns the response. If the request fails (e.g.
+// the connection is refused or it times out), fail the test. Note that a response with an error status code, such as
+// 404 or 500, is not a failure.
+func HTTPDo(t *testing.T, options HTTPRequestOptions) HTTPResponse {
+	response, err := HTTPDoE(t, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// HTTPDoE makes the HTTP request described by the given options and returns the response, or an error if the request
+// fails (e.g. the connection is refused or it times out). Note that a response with an error status code, such as 404
+// or 500, is not an error.
+func HTTPDoE(t *testing.T, options HTTPRequestOptions) (HTTPResponse, error) {
+	client := newHTTPClient(options)
+	defer closeIdleConnections(client)
+
+	return doHTTPRequest(t, client, options)
+}
+
+// Make the HTTP request described by the given options with the given client
+func doHTTPRequest(t *testing.T, client *http.Client, options HTTPRequestOptions) (HTTPResponse, error) {
+	method := options.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	logger.RegisterSecret(options.BasicAuthPassword, options.BearerToken)
+	logger.Logf(t, "Making an HTTP %s call to URL %s", method, options.URL)
+
+	var body io.Reader
+	if options.Body != nil {
+		body = bytes.NewReader(options.Body)
+	}
+
+	request, err := http.NewRequest(method, options.URL, body)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+
+	for key, value := range options.Headers {
+		// Go sends request.Host as the Host header and ignores any Host in request.Header
+		if http.CanonicalHeaderKey(key) == "Host" {
+			request.Host = value
+			continue
+		}
+		request.Header.Set(key, value)
+	}
+	if options.BasicAuthUsername != "" {
+		request.SetBasicAuth(options.BasicAuthUsername, options.BasicAuthPassword)
+	}
+	if options.BearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+options.BearerToken)
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+
+	return HTTPResponse{StatusCode: resp.StatusCode, Headers: resp.Header, Body: respBody}, nil
+}
+
+// Create an HTTP client with the timeout, redirect policy, and TLS settings in the given options. If there are TLS
+// settings, the client gets its own Transport, so make sure to call closeIdleConnections when you're done with it.
+func newHTTPClient(options HTTPRequestOptions) *http.Client {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	if options.TLSConfig != nil {
+		client.Transport = newHTTPTransport(options.TLSConfig)
+	}
+
+	if options.DisableRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else if options.MaxRedirects > 0 {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > options.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", options.MaxRedirects)
+			}
+			return nil
+		}
+	}
+
+	return client
+}
+
+// Create a Transport with the given TLS settings, and otherwise the same settings as http.DefaultTransport, such as its
+// proxy, dial, and TLS handshake timeouts
+func newHTTPTransport(tlsConfig *tls.Config) *http.Transport {
+	defaultTransport, isTransport := http.DefaultTransport.(*http.Transport)
+	if !isTransport {
+		// Something replaced http.DefaultTransport, so fall back to the settings it has out of the box
+		return &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			TLSClientConfig:     tlsConfig,
+		}
+	}
+
+	transport := defaultTransport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+// Close the idle connections of the given client, if it has its own Transport. The connections of
+// http.DefaultTransport are shared with the rest of the process, so they're left alone.
+func closeIdleConnections(client *http.Client) {
+	if client.Transport != nil {
+		client.CloseIdleConnections()
+	}
+}
+
+// HTTPDoWithRetry repeatedly makes the HTTP request described by the given options until the response has the given
+// status code or max retries has been exceeded, and returns the last response. If max retries is exceeded, fail the
+// test.
+func HTTPDoWithRetry(t *testing.T, options HTTPRequestOptions, expectedStatus int, retries int, sleepBetweenRetries time.Duration) HTTPResponse {
+	response, err := HTTPDoWithRetryE(t, options, expectedStatus, retries, sleepBetweenRetries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// HTTPDoWithRetryE repeatedly makes the HTTP request described by the given options until the response has the given
+// status code or max retries has been exceeded, and returns the last response. If max retries is exceeded, return a
+// MaxRetriesExceeded error.
+func HTTPDoWithRetryE(t *testing.T, options HTTPRequestOptions, expectedStatus int, retries int, sleepBetweenRetries time.Duration) (HTTPResponse, error) {
+	return HTTPDoWithRetryWithCustomValidationE(t, options, retries, sleepBetweenRetries, func(response HTTPResponse) bool {
+		return response.StatusCode == expectedStatus
+	})
+}
+
+// HTTPDoWithRetryWithCustomValidation repeatedly makes the HTTP request described by the given options until the given
+// validation function returns true or max retries has been exceeded, and returns the last response. If max retries is
+// exceeded, fail the test.
+func HTTPDoWithRetryWithCustomValidation(t *testing.T, options HTTPRequestOptions, retries int, sleepBetweenRetries time.Duration, validateResponse func(HTTPResponse) bool) HTTPResponse {
+	response, err := HTTPDoWithRetryWithCustomValidationE(t, options, retries, sleepBetweenRetries, validateResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// HTTPDoWithRetryWithCustomValidationE repeatedly makes the HTTP request described by the given options until the given
+// validation function returns true or max retries has been exceeded, and returns the last response. If max retries is
+// exceeded, return a MaxRetriesExceeded error.
+func HTTPDoWithRetryWithCustomValidationE(t *testing.T, options HTTPRequestOptions, retries int, sleepBetweenRetries time.Duration, validateResponse func(HTTPResponse) bool) (HTTPResponse, error) {
+	method := options.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	// Use the same client for every attempt, so attempts can reuse its connections
+	client := newHTTPClient(options)
+	defer closeIdleConnections(client)
+
+	retryOptions := retry.Options{MaxRetries: retries, Backoff: retry.ConstantBackoff{Sleep: sleepBetweenRetries}}
+
+	out, err := retry.DoWithOptionsE(t, fmt.Sprintf("HTTP %s to URL %s", method, options.URL), retryOptions, func() (interface{}, error) {
+		response, err := doHTTPRequest(t, client, options)
+		if err != nil {
+			return response, err
+		}
+		if !validateResponse(response) {
+			return response, ValidationFunctionFailed{Url: options.URL, Status: response.StatusCode, Body: string(response.Body)}
+		}
+		return response, nil
+	})
+
+	response, _ := out.(HTTPResponse)
+	return response, err
+}
@@ -0,0 +1,178 @@
+package http_helper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPDoSendsMethodHeadersBodyAndAuth(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		username, password, _ := r.BasicAuth()
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s %s:%s %s\n", r.Header.Get("Content-Type"), r.Header.Get("X-Custom"), username, password, body)
+	}))
+	defer server.Close()
+
+	response := HTTPDo(t, HTTPRequestOptions{
+		Method:            http.MethodPost,
+		URL:               server.URL,
+		Headers:           map[string]string{"Content-Type": "application/json", "X-Custom": "custom"},
+		Body:              []byte(`{"foo":"bar"}`),
+		BasicAuthUsername: "user",
+		BasicAuthPassword: "http-do-test-password",
+	})
+
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, http.MethodPost, response.Headers.Get("X-Method"))
+	assert.Equal(t, "application/json custom user:http-do-test-password {\"foo\":\"bar\"}\n", string(response.Body))
+}
+
+func TestHTTPDoBearerToken(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	response := HTTPDo(t, HTTPRequestOptions{URL: server.URL, BearerToken: "http-do-test-token"})
+	assert.Equal(t, "Bearer http-do-test-token", string(response.Body))
+}
+
+func TestHTTPDoHostHeader(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Host)
+	}))
+	defer server.Close()
+
+	response := HTTPDo(t, HTTPRequestOptions{URL: server.URL, Headers: map[string]string{"host": "www.example.com"}})
+	assert.Equal(t, "www.example.com", string(response.Body))
+}
+
+func TestHTTPDoRedirects(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "new")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	followed := HTTPDo(t, HTTPRequestOptions{URL: server.URL + "/old"})
+	assert.Equal(t, http.StatusOK, followed.StatusCode)
+	assert.Equal(t, "new", string(followed.Body))
+
+	notFollowed := HTTPDo(t, HTTPRequestOptions{URL: server.URL + "/old", DisableRedirects: true})
+	assert.Equal(t, http.StatusFound, notFollowed.StatusCode)
+	assert.Equal(t, "/new", notFollowed.Headers.Get("Location"))
+}
+
+func TestHTTPDoTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	_, err := HTTPDoE(t, HTTPRequestOptions{URL: server.URL, Timeout: 100 * time.Millisecond})
+	assert.Error(t, err)
+}
+
+func TestHTTPDoTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secure")
+	}))
+	defer server.Close()
+
+	_, err := HTTPDoE(t, HTTPRequestOptions{URL: server.URL})
+	assert.Error(t, err)
+
+	insecure := HTTPDo(t, HTTPRequestOptions{URL: server.URL, TLSConfig: &tls.Config{InsecureSkipVerify: true}})
+	assert.Equal(t, "secure", string(insecure.Body))
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+	trusted := HTTPDo(t, HTTPRequestOptions{URL: server.URL, TLSConfig: &tls.Config{RootCAs: rootCAs}})
+	assert.Equal(t, "secure", string(trusted.Body))
+}
+
+func TestHTTPDoWithRetry(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ready")
+	}))
+	defer server.Close()
+
+	response := HTTPDoWithRetry(t, HTTPRequestOptions{Method: http.MethodPut, URL: server.URL, Body: []byte("body")}, http.StatusOK, 5, 10*time.Millisecond)
+	assert.Equal(t, "ready", string(response.Body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	_, err := HTTPDoWithRetryE(t, HTTPRequestOptions{URL: server.URL}, http.StatusTeapot, 1, 10*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestHTTPDoWithRetryReusesAndClosesTLSConnections(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	var newConnections int32
+	closed := make(chan struct{}, 10)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ready")
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			atomic.AddInt32(&newConnections, 1)
+		case http.StateClosed:
+			closed <- struct{}{}
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	options := HTTPRequestOptions{URL: server.URL, TLSConfig: &tls.Config{InsecureSkipVerify: true}}
+	response := HTTPDoWithRetry(t, options, http.StatusOK, 5, 10*time.Millisecond)
+	assert.Equal(t, "ready", string(response.Body))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&newConnections))
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The connection was not closed after HTTPDoWithRetry returned")
+	}
+}
@@ -3,13 +3,11 @@ package http_helper
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
 	"time"
 
-	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/retry"
 )
 
@@ -22,28 +20,15 @@ func HttpGet(t *testing.T, url string) (int, string) {
 	return statusCode, body
 }
 
-// HttpGetE performs an HTTP GET on the given URL and return the HTTP status code, body, and any error.
+// HttpGetE performs an HTTP GET on the given URL and return the HTTP status code, body, and any error. The body is
+// trimmed of leading and trailing whitespace. To get the raw body, or to customize the request, use HTTPDoE.
 func HttpGetE(t *testing.T, url string) (int, string, error) {
-	logger.Logf(t, "Making an HTTP GET call to URL %s", url)
-
-	client := http.Client{
-		// By default, Go does not impose a timeout, so an HTTP connection attempt can hang for a LONG time.
-		Timeout: 10 * time.Second,
-	}
-
-	resp, err := client.Get(url)
-	if err != nil {
-		return -1, "", err
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-
+	response, err := HTTPDoE(t, HTTPRequestOptions{Method: http.MethodGet, URL: url})
 	if err != nil {
 		return -1, "", err
 	}
 
-	return resp.StatusCode, strings.TrimSpace(string(body)), nil
+	return response.StatusCode, strings.TrimSpace(string(response.Body)), nil
 }
 
 // HttpGetWithValidation performs an HTTP GET on the given URL and verify that you get back the expected status code and body. If either
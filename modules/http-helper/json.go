@@ -0,0 +1,330 @@
+package http_helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// HTTPGetJSON performs an HTTP GET on the given URL, unmarshals the JSON response body into the given value (which
+// must be a pointer, as for json.Unmarshal), and returns the HTTP status code. If there's any error, including a body
+// that isn't valid JSON, fail the test.
+func HTTPGetJSON(t *testing.T, url string, out interface{}) int {
+	statusCode, err := HTTPGetJSONE(t, url, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statusCode
+}
+
+// HTTPGetJSONE performs an HTTP GET on the given URL, unmarshals the JSON response body into the given value (which
+// must be a pointer, as for json.Unmarshal), and returns the HTTP status code. If the body isn't valid JSON, return an
+// InvalidJSONResponse error.
+func HTTPGetJSONE(t *testing.T, url string, out interface{}) (int, error) {
+	statusCode, body, err := HttpGetE(t, url)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if err := json.Unmarshal([]byte(body), out); err != nil {
+		return statusCode, InvalidJSONResponse{Url: url, Status: statusCode, Body: body, Err: err}
+	}
+	return statusCode, nil
+}
+
+// AssertJSONPath checks that the given JSON body satisfies every one of the given JSONPath expressions, and fails the
+// test if it doesn't. See AssertJSONPathE for the supported expressions.
+func AssertJSONPath(t *testing.T, body string, expressions ...string) {
+	err := AssertJSONPathE(t, body, expressions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertJSONPathE checks that the given JSON body satisfies every one of the given JSONPath expressions, and returns a
+// JSONPathAssertionFailed error for the first one it doesn't. Each expression is a path, optionally followed by a
+// comparison with a JSON value:
+//
+//	$.status == "ok"
+//	$.items[0].count >= 3
+//	$['content-type'] != null
+//	$.id
+//
+// A path starts with $, for the whole body, followed by any number of .key, ['key'], ["key"], or [index] parts, where
+// a negative index counts from the end of an array. Wildcards, slices, and filters are not supported. The supported
+// comparisons are ==, !=, <, <=, >, and >=, where the ordering comparisons only work on two numbers or two strings. A
+// path on its own checks that the path exists.
+func AssertJSONPathE(t *testing.T, body string, expressions ...string) error {
+	var document interface{}
+	if err := json.Unmarshal([]byte(body), &document); err != nil {
+		return InvalidJSONResponse{Body: body, Err: err}
+	}
+
+	for _, expression := range expressions {
+		if err := evaluateJSONPathAssertion(document, expression); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONPathValidator returns a function that validates a response by checking that its body is JSON that satisfies
+// every one of the given JSONPath expressions (see AssertJSONPathE), so JSONPath assertions can be used with
+// HttpGetWithCustomValidation, HttpGetWithRetryWithCustomValidation, and ContinuouslyCheck. The status code is not
+// checked.
+func JSONPathValidator(expressions ...string) func(int, string) bool {
+	return func(statusCode int, body string) bool {
+		return AssertJSONPathE(nil, body, expressions...) == nil
+	}
+}
+
+// EvaluateJSONPath returns the value at the given path in the given JSON body, decoded as by json.Unmarshal into an
+// interface{}. See AssertJSONPathE for the supported paths.
+func EvaluateJSONPath(body string, path string) (interface{}, error) {
+	var document interface{}
+	if err := json.Unmarshal([]byte(body), &document); err != nil {
+		return nil, InvalidJSONResponse{Body: body, Err: err}
+	}
+	return evaluateJSONPath(document, path)
+}
+
+// The comparisons a JSONPath assertion can use, longest first, so >= is found before >
+var jsonPathOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// Check that the given decoded JSON document satisfies the given JSONPath expression
+func evaluateJSONPathAssertion(document interface{}, expression string) error {
+	path, operator, expected, err := parseJSONPathAssertion(expression)
+	if err != nil {
+		return err
+	}
+
+	actual, err := evaluateJSONPath(document, path)
+	if _, isInvalidPath := err.(InvalidJSONPath); isInvalidPath {
+		return err
+	} else if err != nil {
+		return JSONPathAssertionFailed{Expression: expression, Reason: err.Error()}
+	}
+	if operator == "" {
+		return nil
+	}
+
+	if ok, err := compareJSONValues(actual, operator, expected); err != nil {
+		return JSONPathAssertionFailed{Expression: expression, Actual: actual, Reason: err.Error()}
+	} else if !ok {
+		return JSONPathAssertionFailed{Expression: expression, Actual: actual}
+	}
+	return nil
+}
+
+// Split the given JSONPath assertion into its path, its operator, and the value to compare with. If the expression is
+// just a path, the operator is empty.
+func parseJSONPathAssertion(expression string) (string, string, interface{}, error) {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "$") {
+		return "", "", nil, InvalidJSONPath{Path: expression, Reason: "it must start with $"}
+	}
+
+	// The path ends at the first space or operator character that isn't in brackets
+	end := len(expression)
+	depth := 0
+	var quote rune
+	for i, char := range expression {
+		if quote != 0 {
+			if char == quote {
+				quote = 0
+			}
+			continue
+		}
+		if depth > 0 && (char == '\'' || char == '"') {
+			quote = char
+		} else if char == '[' {
+			depth++
+		} else if char == ']' {
+			depth--
+		} else if depth == 0 && strings.ContainsRune(" \t=!<>", char) {
+			end = i
+			break
+		}
+	}
+
+	path := expression[:end]
+	rest := strings.TrimSpace(expression[end:])
+	if rest == "" {
+		return path, "", nil, nil
+	}
+
+	for _, operator := range jsonPathOperators {
+		if strings.HasPrefix(rest, operator) {
+			literal := strings.TrimSpace(strings.TrimPrefix(rest, operator))
+			var expected interface{}
+			if err := json.Unmarshal([]byte(literal), &expected); err != nil {
+				return "", "", nil, InvalidJSONPath{Path: expression, Reason: fmt.Sprintf("%s is not a JSON value", literal)}
+			}
+			return path, operator, expected, nil
+		}
+	}
+	return "", "", nil, InvalidJSONPath{Path: expression, Reason: fmt.Sprintf("%s does not start with one of %v", rest, jsonPathOperators)}
+}
+
+// Return the value at the given path in the given decoded JSON document
+func evaluateJSONPath(document interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, InvalidJSONPath{Path: path, Reason: "it must start with $"}
+	}
+
+	current := document
+	rest := path[1:]
+	location := "$"
+
+	for rest != "" {
+		var key string
+		index := 0
+		isIndex := false
+
+		switch {
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key = rest[1 : end+1]
+			if key == "" {
+				return nil, InvalidJSONPath{Path: path, Reason: fmt.Sprintf("empty key after %s", location)}
+			}
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "['") || strings.HasPrefix(rest, `["`):
+			quote := rest[1:2]
+			end := strings.Index(rest[2:], quote+"]")
+			if end < 0 {
+				return nil, InvalidJSONPath{Path: path, Reason: fmt.Sprintf("unterminated key after %s", location)}
+			}
+			key = rest[2 : end+2]
+			rest = rest[end+4:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, InvalidJSONPath{Path: path, Reason: fmt.Sprintf("unterminated index after %s", location)}
+			}
+			parsed, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, InvalidJSONPath{Path: path, Reason: fmt.Sprintf("%s is not an array index", rest[1:end])}
+			}
+			index = parsed
+			isIndex = true
+			rest = rest[end+1:]
+		default:
+			return nil, InvalidJSONPath{Path: path, Reason: fmt.Sprintf("unexpected %s after %s", rest, location)}
+		}
+
+		if isIndex {
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an array", location)
+			}
+			if index < 0 {
+				index += len(array)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("%s has no index %d", location, index)
+			}
+			current = array[index]
+			location = fmt.Sprintf("%s[%d]", location, index)
+		} else {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", location)
+			}
+			value, exists := object[key]
+			if !exists {
+				return nil, fmt.Errorf("%s has no key %s", location, key)
+			}
+			current = value
+			location = fmt.Sprintf("%s[%q]", location, key)
+		}
+	}
+
+	return current, nil
+}
+
+// Compare the given decoded JSON values with the given operator
+func compareJSONValues(actual interface{}, operator string, expected interface{}) (bool, error) {
+	switch operator {
+	case "==":
+		return reflect.DeepEqual(actual, expected), nil
+	case "!=":
+		return !reflect.DeepEqual(actual, expected), nil
+	}
+
+	var comparison int
+	actualNumber, actualIsNumber := actual.(float64)
+	expectedNumber, expectedIsNumber := expected.(float64)
+	actualString, actualIsString := actual.(string)
+	expectedString, expectedIsString := expected.(string)
+
+	switch {
+	case actualIsNumber && expectedIsNumber:
+		if actualNumber < expectedNumber {
+			comparison = -1
+		} else if actualNumber > expectedNumber {
+			comparison = 1
+		}
+	case actualIsString && expectedIsString:
+		comparison = strings.Compare(actualString, expectedString)
+	default:
+		return false, fmt.Errorf("%s only works on two numbers or two strings", operator)
+	}
+
+	switch operator {
+	case "<":
+		return comparison < 0, nil
+	case "<=":
+		return comparison <= 0, nil
+	case ">":
+		return comparison > 0, nil
+	default:
+		return comparison >= 0, nil
+	}
+}
+
+// InvalidJSONResponse is an error that occurs when a response body that should be JSON isn't.
+type InvalidJSONResponse struct {
+	Url    string
+	Status int
+	Body   string
+	Err    error
+}
+
+func (err InvalidJSONResponse) Error() string {
+	if err.Url == "" {
+		return fmt.Sprintf("Body is not valid JSON: %v. Body:\n%s", err.Err, err.Body)
+	}
+	return fmt.Sprintf("Response from URL %s is not valid JSON: %v. Response status: %d. Response body:\n%s", err.Url, err.Err, err.Status, err.Body)
+}
+
+// InvalidJSONPath is an error that occurs when a JSONPath expression can't be parsed.
+type InvalidJSONPath struct {
+	Path   string
+	Reason string
+}
+
+func (err InvalidJSONPath) Error() string {
+	return fmt.Sprintf("Invalid JSONPath expression %s: %s", err.Path, err.Reason)
+}
+
+// JSONPathAssertionFailed is an error that occurs when a JSON body doesn't satisfy a JSONPath expression.
+type JSONPathAssertionFailed struct {
+	Expression string
+	Actual     interface{} // The value at the path, if the path exists
+	Reason     string      // Why the assertion failed, if it's not just that the value didn't match
+}
+
+func (err JSONPathAssertionFailed) Error() string {
+	if err.Reason != "" {
+		return fmt.Sprintf("JSONPath assertion %s failed: %s", err.Expression, err.Reason)
+	}
+	actual, _ := json.Marshal(err.Actual)
+	return fmt.Sprintf("JSONPath assertion %s failed: the actual value is %s", err.Expression, actual)
+}
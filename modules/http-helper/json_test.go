@@ -0,0 +1,114 @@
+package http_helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleJSONBody = `{
+	"status": "ok",
+	"version": 3,
+	"healthy": true,
+	"content-type": "application/json",
+	"items": [{"name": "first", "count": 1}, {"name": "last", "count": 5}],
+	"error": null
+}`
+
+func TestHTTPGetJSON(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	server.AddRoute("/json", DummyResponse{Body: exampleJSONBody})
+	server.AddRoute("/text", DummyResponse{Body: "not json"})
+
+	var response struct {
+		Status string `json:"status"`
+		Items  []struct {
+			Name  string `json:"name"`
+			Count int    `json:"count"`
+		} `json:"items"`
+	}
+	statusCode := HTTPGetJSON(t, server.URL()+"/json", &response)
+	assert.Equal(t, 200, statusCode)
+	assert.Equal(t, "ok", response.Status)
+	assert.Equal(t, 5, response.Items[1].Count)
+
+	_, err := HTTPGetJSONE(t, server.URL()+"/text", &response)
+	assert.IsType(t, InvalidJSONResponse{}, err)
+}
+
+func TestAssertJSONPath(t *testing.T) {
+	t.Parallel()
+
+	AssertJSONPath(t, exampleJSONBody,
+		`$.status == "ok"`,
+		`$.version>=3`,
+		`$.version < 3.5`,
+		`$.healthy == true`,
+		`$['content-type'] == "application/json"`,
+		`$["content-type"] != "text/plain"`,
+		`$.items[0].name == "first"`,
+		`$.items[-1].count > 1`,
+		`$.items[1]`,
+		`$.error == null`,
+		`$.items[0] == {"name": "first", "count": 1}`,
+		`$.status > "nope"`,
+	)
+
+	failures := []string{
+		`$.status == "down"`,
+		`$.missing`,
+		`$.items[2]`,
+		`$.status.nested`,
+		`$.status > 1`,
+		`$.version != 3`,
+	}
+	for _, expression := range failures {
+		err := AssertJSONPathE(t, exampleJSONBody, expression)
+		assert.IsType(t, JSONPathAssertionFailed{}, err, expression)
+	}
+
+	invalid := []string{
+		`status == "ok"`,
+		`$.status = "ok"`,
+		`$.status == ok`,
+		`$.items[first]`,
+		`$..status`,
+	}
+	for _, expression := range invalid {
+		err := AssertJSONPathE(t, exampleJSONBody, expression)
+		assert.Error(t, err, expression)
+		assert.IsType(t, InvalidJSONPath{}, err, expression)
+	}
+}
+
+func TestEvaluateJSONPath(t *testing.T) {
+	t.Parallel()
+
+	value, err := EvaluateJSONPath(exampleJSONBody, "$.items[1].name")
+	assert.NoError(t, err)
+	assert.Equal(t, "last", value)
+
+	value, err = EvaluateJSONPath(exampleJSONBody, "$")
+	assert.NoError(t, err)
+	assert.IsType(t, map[string]interface{}{}, value)
+}
+
+func TestJSONPathValidatorWithRetry(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	server.AddRoute("/status", DummyResponse{Body: `{"status": "starting"}`})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		server.AddRoute("/status", DummyResponse{Body: `{"status": "ok"}`})
+	}()
+
+	HttpGetWithRetryWithCustomValidation(t, server.URL()+"/status", 20, 50*time.Millisecond, JSONPathValidator(`$.status == "ok"`))
+}
@@ -0,0 +1,200 @@
+package http_helper
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// RecordedRequest is a request received by a DummyServer.
+type RecordedRequest struct {
+	Method  string      // The HTTP method (e.g. POST)
+	Path    string      // The path of the URL, without the query string (e.g. /webhook)
+	Query   string      // The query string of the URL, without the leading ? (e.g. foo=bar)
+	Headers http.Header // The request headers
+	Body    []byte      // The request body
+	Time    time.Time   // When the server received the request
+}
+
+// RequestMatcher returns true if the given request is the one a test is looking for.
+type RequestMatcher func(request RecordedRequest) bool
+
+// MatchMethodAndPath returns a RequestMatcher that matches requests with the given method and path. An empty method
+// matches every method.
+func MatchMethodAndPath(method string, path string) RequestMatcher {
+	return func(request RecordedRequest) bool {
+		return (method == "" || request.Method == method) && request.Path == path
+	}
+}
+
+// MatchBodyContains returns a RequestMatcher that matches requests whose body contains the given text.
+func MatchBodyContains(text string) RequestMatcher {
+	return func(request RecordedRequest) bool {
+		return strings.Contains(string(request.Body), text)
+	}
+}
+
+// MatchAll returns a RequestMatcher that matches requests that all the given matchers match.
+func MatchAll(matchers ...RequestMatcher) RequestMatcher {
+	return func(request RecordedRequest) bool {
+		for _, matcher := range matchers {
+			if !matcher(request) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Record the given request, and put its body back, so the handler of the route can still read it
+func (server *DummyServer) record(r *http.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	request := RecordedRequest{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.RawQuery,
+		Headers: r.Header,
+		Body:    body,
+		Time:    time.Now(),
+	}
+
+	server.requestsLock.Lock()
+	defer server.requestsLock.Unlock()
+
+	server.requests = append(server.requests, request)
+	close(server.updated)
+	server.updated = make(chan struct{})
+	return nil
+}
+
+// Requests returns every request the server has received so far, in the order it received them.
+func (server *DummyServer) Requests() []RecordedRequest {
+	server.requestsLock.Lock()
+	defer server.requestsLock.Unlock()
+
+	return append([]RecordedRequest{}, server.requests...)
+}
+
+// Return the requests received so far that match the given matcher, along with a channel that's closed when the
+// server receives another request
+func (server *DummyServer) matchingRequests(matcher RequestMatcher) ([]RecordedRequest, chan struct{}) {
+	server.requestsLock.Lock()
+	defer server.requestsLock.Unlock()
+
+	matches := []RecordedRequest{}
+	for _, request := range server.requests {
+		if matcher(request) {
+			matches = append(matches, request)
+		}
+	}
+	return matches, server.updated
+}
+
+// WaitForRequest waits up to the given timeout for the server to receive a request that matches the given matcher,
+// and returns the first such request. If the server already received one, it returns right away. If no request
+// matches in time, fail the test.
+func (server *DummyServer) WaitForRequest(t *testing.T, matcher RequestMatcher, timeout time.Duration) RecordedRequest {
+	request, err := server.WaitForRequestE(t, matcher, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+// WaitForRequestE waits up to the given timeout for the server to receive a request that matches the given matcher,
+// and returns the first such request. If the server already received one, it returns right away. If no request
+// matches in time, return a RequestNotReceived error.
+func (server *DummyServer) WaitForRequestE(t *testing.T, matcher RequestMatcher, timeout time.Duration) (RecordedRequest, error) {
+	logger.Logf(t, "Waiting up to %s for dummy HTTP server on port %d to receive a matching request", timeout, server.Port())
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		matches, updated := server.matchingRequests(matcher)
+		if len(matches) > 0 {
+			return matches[0], nil
+		}
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return RecordedRequest{}, RequestNotReceived{Port: server.Port(), Timeout: timeout, Received: len(server.Requests())}
+		}
+	}
+}
+
+// AssertReceived checks that the server has received at least one request that matches the given matcher, and fails
+// the test if it has not.
+func (server *DummyServer) AssertReceived(t *testing.T, matcher RequestMatcher) {
+	err := server.AssertReceivedE(t, matcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertReceivedE checks that the server has received at least one request that matches the given matcher, and
+// returns a RequestNotReceived error if it has not.
+func (server *DummyServer) AssertReceivedE(t *testing.T, matcher RequestMatcher) error {
+	matches, _ := server.matchingRequests(matcher)
+	if len(matches) == 0 {
+		return RequestNotReceived{Port: server.Port(), Received: len(server.Requests())}
+	}
+	return nil
+}
+
+// AssertNotReceived checks that the server has not received any request that matches the given matcher, and fails the
+// test if it has.
+func (server *DummyServer) AssertNotReceived(t *testing.T, matcher RequestMatcher) {
+	err := server.AssertNotReceivedE(t, matcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertNotReceivedE checks that the server has not received any request that matches the given matcher, and returns
+// an UnexpectedRequestReceived error if it has.
+func (server *DummyServer) AssertNotReceivedE(t *testing.T, matcher RequestMatcher) error {
+	matches, _ := server.matchingRequests(matcher)
+	if len(matches) > 0 {
+		return UnexpectedRequestReceived{Port: server.Port(), Request: matches[0], Count: len(matches)}
+	}
+	return nil
+}
+
+// RequestNotReceived is an error that occurs when a DummyServer has not received a request that matches a matcher.
+type RequestNotReceived struct {
+	Port     int
+	Timeout  time.Duration // How long we waited for the request, or zero if we didn't wait
+	Received int           // How many requests the server received in total
+}
+
+func (err RequestNotReceived) Error() string {
+	if err.Timeout > 0 {
+		return fmt.Sprintf("Dummy HTTP server on port %d did not receive a matching request within %s (it received %d requests in total)", err.Port, err.Timeout, err.Received)
+	}
+	return fmt.Sprintf("Dummy HTTP server on port %d did not receive a matching request (it received %d requests in total)", err.Port, err.Received)
+}
+
+// UnexpectedRequestReceived is an error that occurs when a DummyServer has received a request that matches a matcher
+// it should not have.
+type UnexpectedRequestReceived struct {
+	Port    int
+	Request RecordedRequest // The first matching request
+	Count   int             // How many matching requests the server received
+}
+
+func (err UnexpectedRequestReceived) Error() string {
+	return fmt.Sprintf("Dummy HTTP server on port %d received %d unexpected matching requests, the first of which was %s %s at %s", err.Port, err.Count, err.Request.Method, err.Request.Path, err.Request.Time.Format(time.RFC3339))
+}
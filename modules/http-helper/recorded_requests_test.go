@@ -0,0 +1,64 @@
+package http_helper
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDummyServerRecordsRequests(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	server.Handle("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		// The handler can still read the body after the server recorded it
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body[:5])
+	})
+
+	response := HTTPDo(t, HTTPRequestOptions{
+		Method:  http.MethodPost,
+		URL:     server.URL() + "/webhook?source=sns",
+		Headers: map[string]string{"X-Amz-Sns-Message-Type": "Notification"},
+		Body:    []byte(`hello from sns`),
+	})
+	assert.Equal(t, "hello", string(response.Body))
+
+	request := server.WaitForRequest(t, MatchMethodAndPath(http.MethodPost, "/webhook"), 5*time.Second)
+	assert.Equal(t, "source=sns", request.Query)
+	assert.Equal(t, "Notification", request.Headers.Get("X-Amz-Sns-Message-Type"))
+	assert.Equal(t, "hello from sns", string(request.Body))
+	assert.False(t, request.Time.IsZero())
+
+	assert.Len(t, server.Requests(), 1)
+	server.AssertReceived(t, MatchAll(MatchMethodAndPath("", "/webhook"), MatchBodyContains("sns")))
+	server.AssertNotReceived(t, MatchMethodAndPath(http.MethodGet, "/webhook"))
+
+	assert.Error(t, server.AssertReceivedE(t, MatchBodyContains("not sent")))
+	assert.Error(t, server.AssertNotReceivedE(t, MatchBodyContains("hello")))
+}
+
+func TestDummyServerWaitForRequest(t *testing.T) {
+	t.Parallel()
+
+	server := NewDummyServer(t)
+	defer shutDownServer(t, server)
+
+	server.AddRoute("/callback", DummyResponse{StatusCode: http.StatusAccepted})
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		HTTPDoE(t, HTTPRequestOptions{Method: http.MethodPut, URL: server.URL() + "/callback", Body: []byte("done")})
+	}()
+
+	request := server.WaitForRequest(t, MatchMethodAndPath(http.MethodPut, "/callback"), 5*time.Second)
+	assert.Equal(t, "done", string(request.Body))
+
+	_, err := server.WaitForRequestE(t, MatchMethodAndPath(http.MethodDelete, "/callback"), 100*time.Millisecond)
+	assert.IsType(t, RequestNotReceived{}, err)
+}
@@ -2,17 +2,33 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
+// SecretMask is the text that replaces registered secrets in log output.
+const SecretMask = "<sensitive>"
+
+var (
+	secrets      = map[string]bool{}
+	secretsMutex sync.RWMutex
+)
+
+// Matches the path of a file in a Terratest module, with or without a version (as in the Go module cache), capturing
+// the name of the module
+var moduleFileRegexp = regexp.MustCompile(`/terratest(?:@[^/]+)?/modules/([^/]+)/[^/]+\.go$`)
+
 // Logf logs the given format and arguments, formatted using fmt.Sprintf, to stdout, along with a timestamp and information
-// about what test and file is doing the logging. This is an alternative to t.Logf that logs to stdout immediately,
+// about what test and file is doing the logging. More precisely, it logs at the info level with the default Logger,
+// which logs to stdout unless it's replaced using SetDefault. This is an alternative to t.Logf that logs to stdout immediately,
 // rather than buffering all log output and only displaying it at the very end of the test. This is useful because:
 //
 // 1. It allows you to iterate faster locally, as you get feedback on whether your code changes are working as expected
@@ -29,23 +45,155 @@ import (
 // Note that there is a proposal to improve t.Logf (https://github.com/golang/go/issues/24929), but until that's
 // implemented, this method is our best bet.
 func Logf(t *testing.T, format string, args ...interface{}) {
-	DoLog(t, 2, os.Stdout, fmt.Sprintf(format, args...))
+	logEntry(t, nil, LevelInfo, 2, fmt.Sprintf(format, args...))
 }
 
 // Log logs the given arguments to stdout, along with a timestamp and information about what test and file is doing the
 // logging. This is an alternative to t.Logf that logs to stdout immediately, rather than buffering all log output and
 // only displaying it at the very end of the test. See the Logf method for more info.
 func Log(t *testing.T, args ...interface{}) {
-	DoLog(t, 2, os.Stdout, args...)
+	logEntry(t, nil, LevelInfo, 2, sprintln(args...))
+}
+
+// Debugf logs the given format and arguments, formatted using fmt.Sprintf, at the debug level with the default Logger.
+func Debugf(t *testing.T, format string, args ...interface{}) {
+	logEntry(t, nil, LevelDebug, 2, fmt.Sprintf(format, args...))
+}
+
+// Infof logs the given format and arguments, formatted using fmt.Sprintf, at the info level with the default Logger.
+// This is the same as Logf.
+func Infof(t *testing.T, format string, args ...interface{}) {
+	logEntry(t, nil, LevelInfo, 2, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs the given format and arguments, formatted using fmt.Sprintf, at the warn level with the default Logger.
+func Warnf(t *testing.T, format string, args ...interface{}) {
+	logEntry(t, nil, LevelWarn, 2, fmt.Sprintf(format, args...))
+}
+
+// Errorf logs the given format and arguments, formatted using fmt.Sprintf, at the error level with the default Logger.
+// Unlike t.Errorf, this does not mark the test as failed.
+func Errorf(t *testing.T, format string, args ...interface{}) {
+	logEntry(t, nil, LevelError, 2, fmt.Sprintf(format, args...))
+}
+
+// LogfTo logs the given format and arguments, formatted using fmt.Sprintf, at the given level with the given Logger,
+// or with the default Logger if the given Logger is nil. Helpers whose Options have a Logger field use this, so the
+// Logger can be overridden for a single call.
+func LogfTo(t *testing.T, logger Logger, level Level, format string, args ...interface{}) {
+	logEntry(t, logger, level, 2, fmt.Sprintf(format, args...))
+}
+
+// LogTo logs the given arguments at the given level with the given Logger, or with the default Logger if the given
+// Logger is nil.
+func LogTo(t *testing.T, logger Logger, level Level, args ...interface{}) {
+	logEntry(t, logger, level, 2, sprintln(args...))
+}
+
+// LogfToModule is like LogfTo, but sets the Module of the log entry to the given module, rather than working it out
+// from the stack. This is for code that logs on behalf of another module, such as the goroutines in the shell module
+// that log the output of a command run by terraform. If the given module is empty, it's worked out from the stack.
+func LogfToModule(t *testing.T, logger Logger, module string, level Level, format string, args ...interface{}) {
+	logModuleEntry(t, logger, module, level, 2, fmt.Sprintf(format, args...))
+}
+
+// LogToModule is like LogTo, but sets the Module of the log entry to the given module. See LogfToModule.
+func LogToModule(t *testing.T, logger Logger, module string, level Level, args ...interface{}) {
+	logModuleEntry(t, logger, module, level, 2, sprintln(args...))
+}
+
+// Format the given arguments as fmt.Sprintln does, but without the trailing newline
+func sprintln(args ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+// Log the given message at the given level with the given Logger, or the default Logger if it's nil. The argument
+// callDepth is the number of stack frames between the code doing the logging and this method, as in DoLog.
+func logEntry(t *testing.T, logger Logger, level Level, callDepth int, message string) {
+	logModuleEntry(t, logger, "", level, callDepth+1, message)
+}
+
+// Log the given message as in logEntry, but with the given module as the Module of the entry, or, if it's empty, the
+// module worked out from the stack
+func logModuleEntry(t *testing.T, logger Logger, module string, level Level, callDepth int, message string) {
+	if logger == nil {
+		logger = Default()
+	}
+	if module == "" {
+		module = callerModule(callDepth + 1)
+	}
+
+	logger.Log(t, Entry{
+		Level:    level,
+		Time:     time.Now(),
+		TestName: t.Name(),
+		Caller:   CallerPrefix(callDepth + 1),
+		Module:   module,
+		Message:  Redact(message),
+	})
 }
 
 // DoLog logs the given arguments to the given writer, along with a timestamp and information about what test and file is
 // doing the logging.
 func DoLog(t *testing.T, callDepth int, writer io.Writer, args ...interface{}) {
-	date := time.Now()
-	prefix := fmt.Sprintf("%s %s %s:", t.Name(), date.Format(time.RFC3339), CallerPrefix(callDepth+1))
-	allArgs := append([]interface{}{prefix}, args...)
-	fmt.Fprintln(writer, allArgs...)
+	entry := Entry{
+		Level:    LevelInfo,
+		Time:     time.Now(),
+		TestName: t.Name(),
+		Caller:   CallerPrefix(callDepth + 1),
+		Message:  Redact(sprintln(args...)),
+	}
+	fmt.Fprint(writer, formatEntry(entry))
+}
+
+// RegisterSecret registers the given values as secrets. From then on, every occurrence of these values in log output
+// written by this package, or passed through Redact, is replaced with SecretMask. Every non-empty value is registered,
+// however short, as a short password is no less secret than a long one, and empty values are ignored. Every Terratest
+// module that masks secrets (e.g., the SensitiveVars of terraform or the passwords and tokens of http-helper) registers
+// them with this function, so they all follow the same rule. Secrets are registered for the lifetime of the process, so a secret registered by one test is also masked in the logs of
+// every other test. The JSON-escaped form of every value (e.g., with " written as \" and < as \u003c) is registered
+// too, so secrets are also masked in logged JSON, such as the output of json.Marshal.
+func RegisterSecret(values ...string) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	for _, value := range values {
+		if value != "" {
+			secrets[value] = true
+			secrets[jsonEscape(value)] = true
+		}
+	}
+}
+
+// Return the given value as json.Marshal writes it inside a JSON string, without the surrounding quotes
+func jsonEscape(value string) string {
+	escaped, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return string(escaped[1 : len(escaped)-1])
+}
+
+// Redact returns the given text with every registered secret (see RegisterSecret) replaced with SecretMask.
+func Redact(text string) string {
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+
+	if len(secrets) == 0 {
+		return text
+	}
+
+	// Replace longer secrets first, so a secret that contains another secret is masked as a whole
+	values := make([]string, 0, len(secrets))
+	for value := range secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, value := range values {
+		text = strings.Replace(text, value, SecretMask, -1)
+	}
+	return text
 }
 
 // CallerPrefix returns the file and line number information about the methods that called this method, based on the current
@@ -69,3 +217,37 @@ func CallerPrefix(callDepth int) string {
 
 	return fmt.Sprintf("%s:%d", file, line)
 }
+
+// Return the name of the Terratest module doing the logging, based on the current goroutine's stack, where the argument
+// callDepth is as in CallerPrefix. Modules call each other (e.g., terraform runs commands using shell, which retries
+// using retry), so this walks up the stack from the caller for as long as the code is in a Terratest module and returns
+// the last one, which is the module the test called. If the caller isn't in a Terratest module, return an empty
+// string. Code that doesn't run on the stack of the test (e.g., in a goroutine) should pass the module explicitly
+// using LogfToModule or LogToModule instead.
+func callerModule(callDepth int) string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(callDepth+2, pcs)])
+
+	module := ""
+	for {
+		frame, more := frames.Next()
+		frameModule := moduleForFile(frame.File)
+		if frameModule == "" {
+			return module
+		}
+		module = frameModule
+		if !more {
+			return module
+		}
+	}
+}
+
+// Return the name of the Terratest module the file at the given path is in, or an empty string if it's not in one. The
+// runtime package reports paths with forward slashes on every OS.
+func moduleForFile(path string) string {
+	matches := moduleFileRegexp.FindStringSubmatch(path)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -19,3 +20,38 @@ func TestDoLog(t *testing.T) {
 
 	assert.Regexp(t, fmt.Sprintf("^%s .+? [[:word:]]+.go:[0-9]+: %s$", t.Name(), text), strings.TrimSpace(buffer.String()))
 }
+
+func TestDoLogRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := "test-do-log-redacts-secrets-password"
+	RegisterSecret(secret, "")
+
+	var buffer bytes.Buffer
+	DoLog(t, 1, &buffer, "the password is", secret)
+
+	assert.NotContains(t, buffer.String(), secret)
+	assert.Contains(t, buffer.String(), "the password is "+SecretMask)
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	RegisterSecret("test-redact-secret", "test-redact-secret-with-suffix")
+
+	assert.Equal(t, "foo <sensitive> bar <sensitive>", Redact("foo test-redact-secret bar test-redact-secret-with-suffix"))
+	assert.Equal(t, "nothing to hide", Redact("nothing to hide"))
+}
+
+func TestRedactJSONEscapedSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := `test-redact-json-<p&ss>"\word`
+	RegisterSecret(secret)
+
+	marshalled, err := json.Marshal(map[string]string{"password": secret})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"password":"`+SecretMask+`"}`, Redact(string(marshalled)))
+	assert.Equal(t, "raw "+SecretMask, Redact("raw "+secret))
+}
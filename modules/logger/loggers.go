@@ -0,0 +1,259 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+// The levels a log entry can have, from least to most severe
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (level Level) String() string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(level))
+	}
+}
+
+// Entry is a single log entry. By the time a Logger gets an Entry, any secrets in the Message have already been
+// masked (see RegisterSecret).
+type Entry struct {
+	Level    Level
+	Time     time.Time
+	TestName string // The name of the test doing the logging
+	Caller   string // The file and line number doing the logging (e.g. cmd.go:42)
+	Module   string // The Terratest module doing the logging (e.g. terraform, ssh, or aws), or empty if it's the test itself
+	Message  string
+}
+
+// Logger writes log entries somewhere. Implement this interface to send the logs of Terratest somewhere other than
+// one of the built-in destinations.
+type Logger interface {
+	Log(t *testing.T, entry Entry)
+}
+
+// LogFormatEnvVarName is the name of the environment variable that picks the format of the default Logger. Set it to
+// LogFormatJSON to log one JSON object per line (see NewJSONLogger) rather than lines of text.
+const LogFormatEnvVarName = "TERRATEST_LOG_FORMAT"
+
+// LogFormatJSON is the value of the LogFormatEnvVarName environment variable that makes the default Logger log JSON.
+const LogFormatJSON = "json"
+
+var (
+	defaultLogger      Logger = WithLevel(newDefaultLogger(os.Getenv(LogFormatEnvVarName)), LevelInfo)
+	defaultLoggerMutex sync.RWMutex
+)
+
+// Return the Logger that writes to stdout in the given format
+func newDefaultLogger(format string) Logger {
+	if strings.ToLower(format) == LogFormatJSON {
+		return NewStdoutJSONLogger()
+	}
+	return NewStdoutLogger()
+}
+
+// Default returns the Logger that Logf, Log, and the other package-level functions use. Unless it's replaced using
+// SetDefault, it logs entries at the info level and above to stdout, as text, or as JSON if the TERRATEST_LOG_FORMAT
+// environment variable is set to json.
+func Default() Logger {
+	defaultLoggerMutex.RLock()
+	defer defaultLoggerMutex.RUnlock()
+
+	return defaultLogger
+}
+
+// SetDefault replaces the Logger that Logf, Log, and the other package-level functions use, for every test in the
+// process. For example, to see debug logs, call SetDefault(WithLevel(NewStdoutLogger(), LevelDebug)) in TestMain.
+func SetDefault(logger Logger) {
+	defaultLoggerMutex.Lock()
+	defer defaultLoggerMutex.Unlock()
+
+	defaultLogger = logger
+}
+
+// Format the given entry as a line of text, as the stdout Logger writes it
+func formatEntry(entry Entry) string {
+	prefix := fmt.Sprintf("%s %s %s:", entry.TestName, entry.Time.Format(time.RFC3339), entry.Caller)
+	if entry.Level != LevelInfo {
+		prefix = fmt.Sprintf("%s [%s]", prefix, entry.Level)
+	}
+	return fmt.Sprintln(prefix, entry.Message)
+}
+
+// WriterLogger writes every log entry as a line of text to a writer.
+type WriterLogger struct {
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+// NewWriterLogger returns a Logger that writes every log entry as a line of text to the given writer.
+func NewWriterLogger(writer io.Writer) *WriterLogger {
+	return &WriterLogger{writer: writer}
+}
+
+// Log writes the given entry to the writer of this Logger.
+func (logger *WriterLogger) Log(t *testing.T, entry Entry) {
+	logger.lock.Lock()
+	defer logger.lock.Unlock()
+
+	fmt.Fprint(logger.writer, formatEntry(entry))
+}
+
+// stdoutLogger looks up os.Stdout on every call, so it keeps working if os.Stdout is replaced
+type stdoutLogger struct{}
+
+// NewStdoutLogger returns a Logger that writes every log entry to stdout right away. This is the default, as it shows
+// the progress of long running tests as it happens. See the Logf method for more info.
+func NewStdoutLogger() Logger {
+	return stdoutLogger{}
+}
+
+func (logger stdoutLogger) Log(t *testing.T, entry Entry) {
+	fmt.Fprint(os.Stdout, formatEntry(entry))
+}
+
+// The fields of a log entry, as the JSON Loggers write it
+type jsonEntry struct {
+	Test    string `json:"test"`
+	Time    string `json:"time"`
+	Caller  string `json:"caller"`
+	Level   string `json:"level"`
+	Module  string `json:"module"`
+	Message string `json:"message"`
+}
+
+// Format the given entry as a line of JSON, as the JSON Loggers write it
+func formatJSONEntry(entry Entry) string {
+	out, err := json.Marshal(jsonEntry{
+		Test:    entry.TestName,
+		Time:    entry.Time.Format(time.RFC3339Nano),
+		Caller:  entry.Caller,
+		Level:   entry.Level.String(),
+		Module:  entry.Module,
+		Message: entry.Message,
+	})
+	if err != nil {
+		// Every field is a string, so this should never happen, but don't lose the message if it does
+		return formatEntry(entry)
+	}
+	return string(out) + "\n"
+}
+
+// JSONLogger writes every log entry as a JSON object, on a line of its own, to a writer.
+type JSONLogger struct {
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+// NewJSONLogger returns a Logger that writes every log entry to the given writer as a JSON object on a line of its
+// own, with the fields test, time, caller, level, module, and message. This makes the logs easy to index and search
+// with log aggregation tools.
+func NewJSONLogger(writer io.Writer) *JSONLogger {
+	return &JSONLogger{writer: writer}
+}
+
+// Log writes the given entry to the writer of this Logger.
+func (logger *JSONLogger) Log(t *testing.T, entry Entry) {
+	logger.lock.Lock()
+	defer logger.lock.Unlock()
+
+	fmt.Fprint(logger.writer, formatJSONEntry(entry))
+}
+
+// stdoutJSONLogger looks up os.Stdout on every call, as stdoutLogger does
+type stdoutJSONLogger struct{}
+
+// NewStdoutJSONLogger returns a Logger that writes every log entry to stdout right away, as a JSON object on a line of
+// its own. See NewJSONLogger for the fields.
+func NewStdoutJSONLogger() Logger {
+	return stdoutJSONLogger{}
+}
+
+func (logger stdoutJSONLogger) Log(t *testing.T, entry Entry) {
+	fmt.Fprint(os.Stdout, formatJSONEntry(entry))
+}
+
+// testingLogger sends every entry through t.Logf
+type testingLogger struct{}
+
+// NewTestingLogger returns a Logger that sends every log entry through t.Logf, so it's buffered and shown per test, as
+// Go shows the rest of the test output. Note that t.Logf reports its own file and line number, which is that of the
+// code in the logger package rather than of the code doing the logging, so the Caller of the entry is included in the
+// message.
+func NewTestingLogger() Logger {
+	return testingLogger{}
+}
+
+func (logger testingLogger) Log(t *testing.T, entry Entry) {
+	t.Logf("%s [%s] %s", entry.Caller, entry.Level, entry.Message)
+}
+
+// discardLogger drops every entry
+type discardLogger struct{}
+
+// Discard is a Logger that drops every log entry. Use it to silence noisy helpers.
+var Discard Logger = discardLogger{}
+
+func (logger discardLogger) Log(t *testing.T, entry Entry) {
+}
+
+// FileLogger writes every log entry as a line of text to a file.
+type FileLogger struct {
+	*WriterLogger
+	file *os.File
+}
+
+// NewFileLogger returns a Logger that appends every log entry as a line of text to the file at the given path, which
+// is created if it doesn't exist. Call Close once you're done with it.
+func NewFileLogger(path string) (*FileLogger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLogger{WriterLogger: NewWriterLogger(file), file: file}, nil
+}
+
+// Close closes the file of this Logger.
+func (logger *FileLogger) Close() error {
+	return logger.file.Close()
+}
+
+// levelLogger drops entries below a minimum level and passes the rest on to another Logger
+type levelLogger struct {
+	logger   Logger
+	minLevel Level
+}
+
+// WithLevel returns a Logger that passes every log entry at the given level or above on to the given Logger, and drops
+// the rest.
+func WithLevel(logger Logger, minLevel Level) Logger {
+	return levelLogger{logger: logger, minLevel: minLevel}
+}
+
+func (logger levelLogger) Log(t *testing.T, entry Entry) {
+	if entry.Level >= logger.minLevel {
+		logger.logger.Log(t, entry)
+	}
+}
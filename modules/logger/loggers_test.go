@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger keeps every entry it gets in memory
+type recordingLogger struct {
+	entries []Entry
+	lock    sync.Mutex
+}
+
+func (logger *recordingLogger) Log(t *testing.T, entry Entry) {
+	logger.lock.Lock()
+	defer logger.lock.Unlock()
+
+	logger.entries = append(logger.entries, entry)
+}
+
+func TestLogfTo(t *testing.T) {
+	t.Parallel()
+
+	secret := "test-logf-to-secret"
+	RegisterSecret(secret)
+
+	recorder := &recordingLogger{}
+	LogfTo(t, recorder, LevelWarn, "disk %d%% full, password %s", 95, secret)
+	LogTo(t, recorder, LevelDebug, "a", "b")
+
+	assert.Len(t, recorder.entries, 2)
+	assert.Equal(t, LevelWarn, recorder.entries[0].Level)
+	assert.Equal(t, "disk 95% full, password "+SecretMask, recorder.entries[0].Message)
+	assert.Equal(t, t.Name(), recorder.entries[0].TestName)
+	assert.Regexp(t, `^loggers_test\.go:[0-9]+$`, recorder.entries[0].Caller)
+	assert.Equal(t, "a b", recorder.entries[1].Message)
+}
+
+func TestWithLevel(t *testing.T) {
+	t.Parallel()
+
+	recorder := &recordingLogger{}
+	logger := WithLevel(recorder, LevelWarn)
+
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		LogfTo(t, logger, level, "message at %s", level)
+	}
+
+	assert.Len(t, recorder.entries, 2)
+	assert.Equal(t, "message at WARN", recorder.entries[0].Message)
+	assert.Equal(t, "message at ERROR", recorder.entries[1].Message)
+}
+
+func TestWriterLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	logger := NewWriterLogger(&buffer)
+
+	LogfTo(t, logger, LevelInfo, "info message")
+	LogfTo(t, logger, LevelError, "error message")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Regexp(t, "^"+t.Name()+` .+? loggers_test\.go:[0-9]+: info message$`, lines[0])
+	assert.Regexp(t, "^"+t.Name()+` .+? loggers_test\.go:[0-9]+: \[ERROR\] error message$`, lines[1])
+}
+
+func TestFileLogger(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "terratest-file-logger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "test.log")
+	logger, err := NewFileLogger(path)
+	assert.NoError(t, err)
+
+	LogfTo(t, logger, LevelInfo, "first")
+	LogfTo(t, logger, LevelInfo, "second")
+	LogfTo(t, Discard, LevelError, "dropped")
+	assert.NoError(t, logger.Close())
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), ": first\n")
+	assert.Contains(t, string(contents), ": second\n")
+	assert.NotContains(t, string(contents), "dropped")
+}
+
+// This test replaces the default Logger, so it must not run in parallel with the other tests in this package
+func TestSetDefault(t *testing.T) {
+	original := Default()
+	defer SetDefault(original)
+
+	recorder := &recordingLogger{}
+	SetDefault(recorder)
+
+	Logf(t, "info %s", "message")
+	Debugf(t, "debug message")
+	Warnf(t, "warn message")
+	LogfTo(t, NewTestingLogger(), LevelInfo, "sent through t.Logf")
+
+	assert.Len(t, recorder.entries, 3)
+	assert.Equal(t, LevelInfo, recorder.entries[0].Level)
+	assert.Equal(t, "info message", recorder.entries[0].Message)
+	assert.Equal(t, LevelDebug, recorder.entries[1].Level)
+	assert.Equal(t, LevelWarn, recorder.entries[2].Level)
+}
+
+func TestJSONLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	logger := NewJSONLogger(&buffer)
+
+	LogfTo(t, logger, LevelWarn, "message with \"quotes\"")
+	LogfTo(t, logger, LevelInfo, "second message")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var entry map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, t.Name(), entry["test"])
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, `message with "quotes"`, entry["message"])
+	assert.Regexp(t, `^loggers_test\.go:[0-9]+$`, entry["caller"])
+	assert.Contains(t, entry, "module")
+
+	_, err := time.Parse(time.RFC3339Nano, entry["time"])
+	assert.NoError(t, err)
+}
+
+func TestLogToModule(t *testing.T) {
+	t.Parallel()
+
+	recorder := &recordingLogger{}
+	LogfToModule(t, recorder, "terraform", LevelInfo, "line %d", 1)
+	LogToModule(t, recorder, "packer", LevelInfo, "line", 2)
+	LogfToModule(t, recorder, "", LevelInfo, "line 3")
+
+	assert.Len(t, recorder.entries, 3)
+	assert.Equal(t, "terraform", recorder.entries[0].Module)
+	assert.Equal(t, "line 1", recorder.entries[0].Message)
+	assert.Regexp(t, `^loggers_test\.go:[0-9]+$`, recorder.entries[0].Caller)
+	assert.Equal(t, "packer", recorder.entries[1].Module)
+	assert.Equal(t, "line 2", recorder.entries[1].Message)
+	assert.Equal(t, callerModule(0), recorder.entries[2].Module)
+}
+
+func TestModuleForFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/go/src/github.com/gruntwork-io/terratest/modules/terraform/cmd.go", "terraform"},
+		{"/go/pkg/mod/github.com/gruntwork-io/terratest@v0.13.0/modules/ssh/ssh.go", "ssh"},
+		{"C:/go/src/github.com/gruntwork-io/terratest/modules/aws/ec2.go", "aws"},
+		{"/go/src/github.com/gruntwork-io/terratest/test/terraform_basic_example_test.go", ""},
+		{"/home/user/infra/modules/vpc/vpc_test.go", ""},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, moduleForFile(testCase.path), testCase.path)
+	}
+}
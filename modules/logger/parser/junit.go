@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The subset of the JUnit XML format that CI systems, such as CircleCI and Jenkins, understand
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnitXML writes the report to the given writer in the JUnit XML format, with one test suite per Go package.
+// The log output of every test that didn't pass is included in its failure, error, or skipped element, and the log
+// output of every test that passed is included as its system-out.
+func (report *Report) WriteJUnitXML(writer io.Writer) error {
+	suitesByPackage := map[string]*junitTestSuite{}
+	seconds := map[string]float64{}
+
+	for _, test := range report.Tests {
+		suite, exists := suitesByPackage[test.Package]
+		if !exists {
+			suite = &junitTestSuite{Name: test.Package}
+			suitesByPackage[test.Package] = suite
+		}
+
+		log := strings.Join(test.Lines, "\n")
+		testCase := junitTestCase{ClassName: test.Package, Name: test.Name, Time: formatSeconds(test.Duration.Seconds())}
+
+		switch test.Status {
+		case StatusPass:
+			testCase.SystemOut = log
+		case StatusFail:
+			testCase.Failure = &junitMessage{Message: "Failed", Body: log}
+			suite.Failures++
+		case StatusSkip:
+			testCase.Skipped = &junitMessage{Message: "Skipped", Body: log}
+			suite.Skipped++
+		default:
+			testCase.Error = &junitMessage{Message: "No test result found, so the test may have timed out or panicked", Body: log}
+			suite.Errors++
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+		// Subtests are included in the time of their parent test, so only count top level tests
+		if !strings.Contains(test.Name, "/") {
+			seconds[test.Package] += test.Duration.Seconds()
+		}
+	}
+
+	packages := make([]string, 0, len(suitesByPackage))
+	for pkg := range suitesByPackage {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	suites := junitTestSuites{}
+	for _, pkg := range packages {
+		suite := suitesByPackage[pkg]
+		suite.Time = formatSeconds(seconds[pkg])
+		suites.Suites = append(suites.Suites, *suite)
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,197 @@
+// Package parser parses the output of go test -v, which interleaves the logs of every test that runs in parallel, and
+// splits it up per test, so each test's logs can be read on their own and turned into a JUnit XML report.
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// The statuses a test can have
+const (
+	StatusPass    = "PASS"
+	StatusFail    = "FAIL"
+	StatusSkip    = "SKIP"
+	StatusUnknown = "UNKNOWN" // The test started, but its result never showed up (e.g., because go test timed out or panicked)
+)
+
+// TestResult is the result and the log output of a single test (or subtest).
+type TestResult struct {
+	Name     string        // The name of the test (e.g. TestFoo or TestFoo/subtest)
+	Package  string        // The Go package of the test, if the output contains the ok/FAIL line for that package
+	Status   string        // One of StatusPass, StatusFail, StatusSkip, or StatusUnknown
+	Duration time.Duration // How long the test took, as reported by go test
+	Lines    []string      // Every line of log output for this test, in order
+}
+
+// Report is the parsed output of go test -v.
+type Report struct {
+	Tests      []*TestResult // Every test in the output, in the order they started
+	Unassigned []string      // Lines of output that don't belong to any test (e.g. the output of go test itself)
+
+	testsInPackage map[string]*TestResult // The tests of the package go test is running, by name
+}
+
+var (
+	// The prefix that logger.DoLog writes: <test name> <RFC3339 timestamp> <file>:<line>:
+	logPrefixRegexp = regexp.MustCompile(`^(\S+) \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2}) \S+:\d+:`)
+	// === RUN   TestFoo, === PAUSE TestFoo, === CONT  TestFoo, and === NAME  TestFoo, which go test 1.20 and newer
+	// prints whenever the output switches to another test
+	testEventRegexp = regexp.MustCompile(`^=== (?:RUN|PAUSE|CONT|NAME)\s+(\S+)`)
+	// --- PASS: TestFoo (1.23s), which is indented for subtests
+	testResultRegexp = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([0-9.]+)s\)`)
+	// ok      github.com/foo/bar  1.234s or FAIL    github.com/foo/bar  1.234s
+	packageResultRegexp = regexp.MustCompile(`^(?:ok|FAIL)\s+(\S+)\s+`)
+)
+
+// Parse reads the output of go test -v from the given reader and splits it up per test. Lines written by the logger
+// package are assigned to the test named in their prefix, or in their test field, if they're in the JSON format (see
+// logger.NewJSONLogger). Every other line (e.g. the output of t.Log, which go test
+// prints after the --- PASS/FAIL line of the test, or a panic) is assigned to the test that last showed up in the
+// output.
+func Parse(reader io.Reader) (*Report, error) {
+	report := &Report{testsInPackage: map[string]*TestResult{}}
+	var current *TestResult
+
+	scanner := bufio.NewScanner(reader)
+	// Log lines, such as terraform output or big JSON documents, can be much longer than the default limit
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if matches := testEventRegexp.FindStringSubmatch(line); matches != nil {
+			current = report.test(matches[1])
+			current.Lines = append(current.Lines, line)
+		} else if matches := testResultRegexp.FindStringSubmatch(line); matches != nil {
+			current = report.test(matches[2])
+			current.Status = matches[1]
+			if seconds, err := strconv.ParseFloat(matches[3], 64); err == nil {
+				current.Duration = time.Duration(seconds * float64(time.Second))
+			}
+			current.Lines = append(current.Lines, strings.TrimSpace(line))
+		} else if matches := logPrefixRegexp.FindStringSubmatch(line); matches != nil {
+			current = report.test(matches[1])
+			current.Lines = append(current.Lines, line)
+		} else if testName := jsonLogTestName(line); testName != "" {
+			current = report.test(testName)
+			current.Lines = append(current.Lines, line)
+		} else if matches := packageResultRegexp.FindStringSubmatch(line); matches != nil {
+			report.setPackage(matches[1])
+			report.Unassigned = append(report.Unassigned, line)
+			current = nil
+		} else if current != nil {
+			current.Lines = append(current.Lines, line)
+		} else {
+			report.Unassigned = append(report.Unassigned, line)
+		}
+	}
+
+	return report, scanner.Err()
+}
+
+// If the given line is a log entry in the JSON format of the logger package, return the name of its test. Otherwise,
+// return an empty string.
+func jsonLogTestName(line string) string {
+	if !strings.HasPrefix(line, "{") {
+		return ""
+	}
+
+	var entry struct {
+		Test string `json:"test"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return ""
+	}
+	return entry.Test
+}
+
+// Return the test with the given name in the package go test is running, adding it to the report if it's not there
+// yet. Tests are looked up by name only within a package, as different packages can have tests with the same name.
+func (report *Report) test(name string) *TestResult {
+	if test, exists := report.testsInPackage[name]; exists {
+		return test
+	}
+
+	test := &TestResult{Name: name, Status: StatusUnknown}
+	report.testsInPackage[name] = test
+	report.Tests = append(report.Tests, test)
+	return test
+}
+
+// go test prints the result of a package after all the tests in it, so every test without a package so far is in the
+// given package, and every test after this is in another package
+func (report *Report) setPackage(pkg string) {
+	for _, test := range report.testsInPackage {
+		test.Package = pkg
+	}
+	report.testsInPackage = map[string]*TestResult{}
+}
+
+// Count returns the number of tests in the report with the given status.
+func (report *Report) Count(status string) int {
+	count := 0
+	for _, test := range report.Tests {
+		if test.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteTestLogs writes the log output of every test to its own file, called <package>/<test name>.log, in the given
+// folder, so tests with the same name in different packages don't overwrite each other. The folders are created if
+// they don't exist. The / in the names of subtests is replaced with _, so every test of a package is in the same
+// folder. Tests whose package isn't known are written to the given folder itself.
+func (report *Report) WriteTestLogs(outputDir string) error {
+	for _, test := range report.Tests {
+		path := filepath.Join(outputDir, TestLogFilePath(test))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		contents := strings.Join(test.Lines, "\n") + "\n"
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestLogFilePath returns the path, relative to the output folder, that WriteTestLogs writes the log output of the
+// given test to.
+func TestLogFilePath(test *TestResult) string {
+	return filepath.Join(filepath.FromSlash(test.Package), TestLogFileName(test.Name))
+}
+
+// TestLogFileName returns the name of the file WriteTestLogs writes the log output of the test with the given name to.
+func TestLogFileName(testName string) string {
+	return strings.Replace(testName, "/", "_", -1) + ".log"
+}
+
+// Summary returns a table with the package, status, and duration of every test, followed by the number of tests with
+// each status.
+func (report *Report) Summary() string {
+	var out bytes.Buffer
+	writer := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "PACKAGE\tTEST\tSTATUS\tDURATION")
+	for _, test := range report.Tests {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", test.Package, test.Name, test.Status, test.Duration)
+	}
+	writer.Flush()
+
+	fmt.Fprintf(&out, "\n%d tests: %d passed, %d failed, %d skipped, %d unknown\n", len(report.Tests), report.Count(StatusPass), report.Count(StatusFail), report.Count(StatusSkip), report.Count(StatusUnknown))
+	return out.String()
+}
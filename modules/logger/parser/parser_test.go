@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleTestOutput = `=== RUN   TestFoo
+=== PAUSE TestFoo
+=== RUN   TestBar
+=== PAUSE TestBar
+=== RUN   TestBaz
+--- SKIP: TestBaz (0.00s)
+    baz_test.go:10: Skipping as SKIP_BAZ is set
+=== CONT  TestFoo
+=== CONT  TestBar
+TestFoo 2018-05-29T20:32:47Z cmd.go:42: Running terraform [init]
+TestBar 2018-05-29T20:32:47Z cmd.go:42: Running terraform [apply]
+TestFoo 2018-05-29T20:32:48Z output.go:146: Terraform has been successfully initialized!
+TestBar 2018-05-29T20:32:49Z output.go:146: Error: something went wrong
+{"test":"TestFoo","time":"2018-05-29T20:32:49.5Z","caller":"cmd.go:42","level":"INFO","module":"terraform","message":"Running terraform [apply]"}
+=== RUN   TestFoo/subtest
+TestFoo/subtest 2018-05-29T20:32:50Z foo_test.go:30: In the subtest
+--- FAIL: TestBar (2.50s)
+    bar_test.go:20: Expected no error
+--- PASS: TestFoo (3.00s)
+    --- PASS: TestFoo/subtest (1.00s)
+=== RUN   TestTimesOut
+TestTimesOut 2018-05-29T20:32:51Z foo_test.go:40: Waiting forever
+FAIL
+FAIL	github.com/gruntwork-io/terratest/test	3.512s
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	report, err := Parse(strings.NewReader(exampleTestOutput))
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, test := range report.Tests {
+		names = append(names, test.Name)
+		assert.Equal(t, "github.com/gruntwork-io/terratest/test", test.Package)
+	}
+	assert.Equal(t, []string{"TestFoo", "TestBar", "TestBaz", "TestFoo/subtest", "TestTimesOut"}, names)
+
+	foo := report.Tests[0]
+	assert.Equal(t, StatusPass, foo.Status)
+	assert.Equal(t, 3*time.Second, foo.Duration)
+	assert.Equal(t, []string{
+		"=== RUN   TestFoo",
+		"=== PAUSE TestFoo",
+		"=== CONT  TestFoo",
+		"TestFoo 2018-05-29T20:32:47Z cmd.go:42: Running terraform [init]",
+		"TestFoo 2018-05-29T20:32:48Z output.go:146: Terraform has been successfully initialized!",
+		`{"test":"TestFoo","time":"2018-05-29T20:32:49.5Z","caller":"cmd.go:42","level":"INFO","module":"terraform","message":"Running terraform [apply]"}`,
+		"--- PASS: TestFoo (3.00s)",
+	}, foo.Lines)
+
+	bar := report.Tests[1]
+	assert.Equal(t, StatusFail, bar.Status)
+	assert.Equal(t, 2500*time.Millisecond, bar.Duration)
+	assert.Contains(t, bar.Lines, "TestBar 2018-05-29T20:32:49Z output.go:146: Error: something went wrong")
+	assert.Contains(t, bar.Lines, "    bar_test.go:20: Expected no error")
+	assert.NotContains(t, bar.Lines, "TestFoo 2018-05-29T20:32:47Z cmd.go:42: Running terraform [init]")
+
+	baz := report.Tests[2]
+	assert.Equal(t, StatusSkip, baz.Status)
+	assert.Contains(t, baz.Lines, "    baz_test.go:10: Skipping as SKIP_BAZ is set")
+
+	assert.Equal(t, StatusPass, report.Tests[3].Status)
+	assert.Equal(t, StatusUnknown, report.Tests[4].Status)
+	assert.Contains(t, report.Tests[4].Lines, "FAIL")
+	assert.Equal(t, []string{"FAIL\tgithub.com/gruntwork-io/terratest/test\t3.512s"}, report.Unassigned)
+
+	summary := report.Summary()
+	assert.Contains(t, summary, "5 tests: 2 passed, 1 failed, 1 skipped, 1 unknown")
+}
+
+func TestWriteTestLogs(t *testing.T) {
+	t.Parallel()
+
+	report, err := Parse(strings.NewReader(exampleTestOutput))
+	assert.NoError(t, err)
+
+	tmpDir, err := ioutil.TempDir("", "terratest-log-parser")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	outputDir := filepath.Join(tmpDir, "logs")
+	assert.NoError(t, report.WriteTestLogs(outputDir))
+
+	packageDir := filepath.Join(outputDir, "github.com", "gruntwork-io", "terratest", "test")
+	subtestLog, err := ioutil.ReadFile(filepath.Join(packageDir, "TestFoo_subtest.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "=== RUN   TestFoo/subtest\nTestFoo/subtest 2018-05-29T20:32:50Z foo_test.go:30: In the subtest\n--- PASS: TestFoo/subtest (1.00s)\n", string(subtestLog))
+
+	files, err := ioutil.ReadDir(packageDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 5)
+}
+
+func TestWriteJUnitXML(t *testing.T) {
+	t.Parallel()
+
+	report, err := Parse(strings.NewReader(exampleTestOutput))
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, report.WriteJUnitXML(&buffer))
+
+	xml := buffer.String()
+	assert.Contains(t, xml, `<testsuite name="github.com/gruntwork-io/terratest/test" tests="5" failures="1" errors="1" skipped="1" time="5.500">`)
+	assert.Contains(t, xml, `<testcase classname="github.com/gruntwork-io/terratest/test" name="TestBar" time="2.500">`)
+	assert.Contains(t, xml, `<failure message="Failed">`)
+	assert.Contains(t, xml, `Error: something went wrong`)
+	assert.Contains(t, xml, `<skipped message="Skipped">`)
+	assert.Contains(t, xml, `<error message="No test result found`)
+}
+
+func TestParseNameEvents(t *testing.T) {
+	t.Parallel()
+
+	// Go 1.20 and newer print === NAME whenever the output switches to another test, and t.Log output has no prefix
+	output := `=== RUN   TestA
+=== PAUSE TestA
+=== RUN   TestB
+=== PAUSE TestB
+=== CONT  TestA
+=== CONT  TestB
+=== NAME  TestA
+    a_test.go:10: log A
+--- PASS: TestA (0.01s)
+=== NAME  TestB
+    b_test.go:10: log B
+--- PASS: TestB (0.01s)
+PASS
+ok  	github.com/gruntwork-io/terratest/test	0.020s
+`
+
+	report, err := Parse(strings.NewReader(output))
+	assert.NoError(t, err)
+	assert.Len(t, report.Tests, 2)
+
+	assert.Equal(t, "TestA", report.Tests[0].Name)
+	assert.Contains(t, report.Tests[0].Lines, "    a_test.go:10: log A")
+	assert.NotContains(t, report.Tests[0].Lines, "    b_test.go:10: log B")
+
+	assert.Equal(t, "TestB", report.Tests[1].Name)
+	assert.Contains(t, report.Tests[1].Lines, "    b_test.go:10: log B")
+	assert.NotContains(t, report.Tests[1].Lines, "    a_test.go:10: log A")
+}
+
+func TestParseSameTestNameInDifferentPackages(t *testing.T) {
+	t.Parallel()
+
+	output := `=== RUN   TestA
+    a_test.go:10: log from p1
+--- PASS: TestA (0.01s)
+PASS
+ok  	example.com/p1	0.020s
+=== RUN   TestA
+    a_test.go:10: log from p2
+--- FAIL: TestA (0.02s)
+FAIL
+FAIL	example.com/p2	0.030s
+`
+
+	report, err := Parse(strings.NewReader(output))
+	assert.NoError(t, err)
+	assert.Len(t, report.Tests, 2)
+
+	assert.Equal(t, "example.com/p1", report.Tests[0].Package)
+	assert.Equal(t, StatusPass, report.Tests[0].Status)
+	assert.Contains(t, report.Tests[0].Lines, "    a_test.go:10: log from p1")
+	assert.NotContains(t, report.Tests[0].Lines, "    a_test.go:10: log from p2")
+
+	assert.Equal(t, "example.com/p2", report.Tests[1].Package)
+	assert.Equal(t, StatusFail, report.Tests[1].Status)
+	assert.Contains(t, report.Tests[1].Lines, "    a_test.go:10: log from p2")
+
+	tmpDir, err := ioutil.TempDir("", "terratest-log-parser")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	assert.NoError(t, report.WriteTestLogs(tmpDir))
+
+	p1Log, err := ioutil.ReadFile(filepath.Join(tmpDir, "example.com", "p1", "TestA.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(p1Log), "log from p1")
+
+	p2Log, err := ioutil.ReadFile(filepath.Join(tmpDir, "example.com", "p2", "TestA.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(p2Log), "log from p2")
+}
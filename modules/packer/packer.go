@@ -6,17 +6,24 @@ import (
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/shell"
 )
 
 // Options are the options for Packer.
 type Options struct {
-	Template string            // The path to the Packer template
-	Vars     map[string]string // The custom vars to pass when running the build command
-	Only     string            // If specified, only run the build of this name
-	Env      map[string]string // Custom environment variables to set when running Packer
+	Template           string            // The path to the Packer template
+	Vars               map[string]string // The custom vars to pass when running the build command
+	Only               string            // If specified, only run the build of this name
+	Env                map[string]string // Custom environment variables to set when running Packer
+	Container          *shell.Container  // If set, Packer runs in this Docker container (e.g. one started from the hashicorp/packer image) rather than on this machine
+	RetryableErrors    map[string]string // If Packer fails with one of these (transient) errors, retry. The keys are regular expressions (escape plain text with regexp.QuoteMeta) to look for in the output and error and the message is what to display to a user if that error is found.
+	MaxRetries         int               // Maximum number of times to retry errors matching RetryableErrors
+	TimeBetweenRetries time.Duration     // The amount of time to wait between retries
+	Logger             logger.Logger     `json:"-"` // The Logger to log Packer and its output with. Defaults to the default Logger of the logger package.
 }
 
 // BuildAmi builds the given Packer template and return the generated AMI ID.
@@ -30,15 +37,22 @@ func BuildAmi(t *testing.T, options *Options) string {
 
 // BuildAmiE builds the given Packer template and return the generated AMI ID.
 func BuildAmiE(t *testing.T, options *Options) (string, error) {
-	logger.Logf(t, "Running Packer to generate AMI for template %s", options.Template)
+	logger.LogfTo(t, options.Logger, logger.LevelInfo, "Running Packer to generate AMI for template %s", options.Template)
 
 	cmd := shell.Command{
-		Command: "packer",
-		Args:    formatPackerArgs(options),
-		Env:     options.Env,
+		Command:   "packer",
+		Args:      formatPackerArgs(options),
+		Env:       options.Env,
+		Container: options.Container,
+		Logger:    options.Logger,
+		LogModule: "packer",
 	}
 
-	output, err := shell.RunCommandAndGetOutputE(t, cmd)
+	retryOptions := retry.Options{MaxRetries: options.MaxRetries, Backoff: retry.ConstantBackoff{Sleep: options.TimeBetweenRetries}, Logger: options.Logger}
+	description := fmt.Sprintf("Running packer %v", cmd.Args)
+	output, err := retry.DoWithRetryableErrorsAndOptionsE(t, description, options.RetryableErrors, retryOptions, func() (string, error) {
+		return shell.RunCommandAndGetOutputE(t, cmd)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -2,7 +2,11 @@ package packer
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
 )
 
 func TestExtractAmiIdFromOneLine(t *testing.T) {
@@ -61,3 +65,59 @@ func TestExtractAmiIdNoIdPresent(t *testing.T) {
 	}
 
 }
+
+func TestBuildAmiWithStubPacker(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "packer", shell.StubResponse{Stdout: "1456332887,amazon-ebs,artifact,0,id,us-east-1:ami-b481b3de\n"})
+
+	options := &Options{
+		Template: "template.json",
+		Vars:     map[string]string{"foo": "bar"},
+		Env:      stubs.EnvVars(),
+	}
+
+	amiID, err := BuildAmiE(t, options)
+	if err != nil {
+		t.Fatalf("Did not expect an error when building with the stub packer: %s", err)
+	}
+	if amiID != "ami-b481b3de" {
+		t.Errorf("Did not get expected AMI ID. Expected: ami-b481b3de. Actual: %s.", amiID)
+	}
+
+	invocations := stubs.Invocations(t, "packer")
+	expectedArgs := []string{"build", "-machine-readable", "-var", "foo=bar", "template.json"}
+	if len(invocations) != 1 || strings.Join(invocations[0].Args, " ") != strings.Join(expectedArgs, " ") {
+		t.Errorf("Expected packer to be invoked once with args %v, but got %v", expectedArgs, invocations)
+	}
+}
+
+func TestBuildAmiRetriesOnRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "packer",
+		shell.StubResponse{Stdout: "1456332887,amazon-ebs,error,Error: RequestLimitExceeded\n", ExitCode: 1},
+		shell.StubResponse{Stdout: "1456332887,amazon-ebs,artifact,0,id,us-east-1:ami-b481b3de\n"},
+	)
+
+	options := &Options{
+		Template:           "template.json",
+		Env:                stubs.EnvVars(),
+		RetryableErrors:    map[string]string{"RequestLimitExceeded": "AWS API rate limit"},
+		MaxRetries:         2,
+		TimeBetweenRetries: time.Millisecond,
+	}
+
+	amiID, err := BuildAmiE(t, options)
+	if err != nil {
+		t.Fatalf("Did not expect an error when the first failure is retryable: %s", err)
+	}
+	if amiID != "ami-b481b3de" {
+		t.Errorf("Did not get expected AMI ID. Expected: ami-b481b3de. Actual: %s.", amiID)
+	}
+	if invocations := stubs.Invocations(t, "packer"); len(invocations) != 2 {
+		t.Errorf("Expected packer to be invoked twice, but it was invoked %d times", len(invocations))
+	}
+}
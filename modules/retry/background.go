@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// BackgroundResult is the result of a single run of the action of a BackgroundRunner.
+type BackgroundResult struct {
+	Start    time.Time     // When the run started
+	Duration time.Duration // How long the run took
+	Output   interface{}   // The value the action returned
+	Err      error         // The error the action returned
+}
+
+// BackgroundRunner runs an action in the background repeatedly, and records the result of every run, until it is
+// stopped. Create one with RunInBackground.
+type BackgroundRunner struct {
+	t           *testing.T
+	description string
+	stop        chan struct{} // Closed to tell the background goroutine to stop
+	stopped     chan struct{} // Closed once the background goroutine has exited
+	stopOnce    sync.Once
+	results     []BackgroundResult
+	lock        sync.Mutex
+}
+
+// RunInBackground runs the specified action in the background (in a goroutine) repeatedly, waiting the specified amount
+// of time between repetitions, and records what the action returns every time. To stop the action, call Stop on the
+// returned BackgroundRunner. The action is also stopped when the test and all its subtests complete, so a forgotten
+// Stop can't leave the goroutine running into other tests.
+func RunInBackground(t *testing.T, actionDescription string, sleepBetweenRepeats time.Duration, action func() (interface{}, error)) *BackgroundRunner {
+	return runInBackground(t, actionDescription, func(start time.Time) time.Duration {
+		return sleepBetweenRepeats
+	}, action)
+}
+
+// RunInBackgroundEvery runs the specified action in the background (in a goroutine) every interval, and records what
+// the action returns every time. Unlike RunInBackground, the interval is measured from the start of one run to the
+// start of the next, so the time the action takes doesn't stretch the period. If a run takes longer than the interval,
+// the next run starts as soon as it completes. To stop the action, call Stop on the returned BackgroundRunner. The
+// action is also stopped when the test and all its subtests complete.
+func RunInBackgroundEvery(t *testing.T, actionDescription string, interval time.Duration, action func() (interface{}, error)) *BackgroundRunner {
+	return runInBackground(t, actionDescription, func(start time.Time) time.Duration {
+		if sleep := interval - time.Since(start); sleep > 0 {
+			return sleep
+		}
+		return 0
+	}, action)
+}
+
+// Run the given action in the background repeatedly, sleeping between runs for as long as the given function returns
+// for the start time of the run that just completed
+func runInBackground(t *testing.T, actionDescription string, sleepAfter func(start time.Time) time.Duration, action func() (interface{}, error)) *BackgroundRunner {
+	runner := &BackgroundRunner{
+		t:           t,
+		description: actionDescription,
+		stop:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(runner.stopped)
+
+		for {
+			logger.Logf(t, "Executing action '%s'", actionDescription)
+
+			start := time.Now()
+			out, err := action()
+			runner.addResult(BackgroundResult{Start: start, Duration: time.Since(start), Output: out, Err: err})
+
+			sleep := sleepAfter(start)
+			logger.Logf(t, "Sleeping for %s before repeating action '%s'", sleep, actionDescription)
+
+			select {
+			case <-time.After(sleep):
+				// Nothing to do, just allow the loop to continue
+			case <-runner.stop:
+				logger.Logf(t, "Received stop signal for action '%s'.", actionDescription)
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(runner.Stop)
+
+	return runner
+}
+
+func (runner *BackgroundRunner) addResult(result BackgroundResult) {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	runner.results = append(runner.results, result)
+}
+
+// Stop tells the action to stop repeating and waits for the background goroutine to exit. If the action is running
+// when Stop is called, Stop waits for that run to complete. It's safe to call Stop more than once.
+func (runner *BackgroundRunner) Stop() {
+	runner.stopOnce.Do(func() {
+		close(runner.stop)
+	})
+	<-runner.stopped
+}
+
+// Results returns the result of every run of the action so far, in the order they happened.
+func (runner *BackgroundRunner) Results() []BackgroundResult {
+	runner.lock.Lock()
+	defer runner.lock.Unlock()
+
+	return append([]BackgroundResult{}, runner.results...)
+}
+
+// Failures returns the result of every run of the action so far that returned an error, in the order they happened.
+func (runner *BackgroundRunner) Failures() []BackgroundResult {
+	failures := []BackgroundResult{}
+	for _, result := range runner.Results() {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
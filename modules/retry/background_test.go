@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInBackground(t *testing.T) {
+	t.Parallel()
+
+	expectedError := errors.New("expected error")
+	runs := 0
+
+	runner := RunInBackground(t, t.Name(), 10*time.Millisecond, func() (interface{}, error) {
+		runs++
+		if runs%2 == 0 {
+			return nil, expectedError
+		}
+		return runs, nil
+	})
+
+	time.Sleep(200 * time.Millisecond)
+	runner.Stop()
+
+	results := runner.Results()
+	assert.True(t, len(results) >= 4, "expected at least 4 runs, got %d", len(results))
+	assert.Equal(t, 1, results[0].Output)
+	assert.Equal(t, expectedError, results[1].Err)
+	assert.Len(t, runner.Failures(), len(results)/2)
+
+	// Once Stop returns, the action never runs again, and stopping again is a no-op
+	runner.Stop()
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, runner.Results(), len(results))
+}
+
+func TestRunInBackgroundStopWaitsForAction(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan bool)
+	finished := false
+
+	runner := RunInBackground(t, t.Name(), time.Hour, func() (interface{}, error) {
+		started <- true
+		time.Sleep(100 * time.Millisecond)
+		finished = true
+		return nil, nil
+	})
+
+	<-started
+	runner.Stop()
+	assert.True(t, finished)
+}
+
+func TestRunInBackgroundStopsOnCleanup(t *testing.T) {
+	t.Parallel()
+
+	var runner *BackgroundRunner
+	t.Run("subtest", func(t *testing.T) {
+		runner = RunInBackground(t, t.Name(), time.Millisecond, func() (interface{}, error) {
+			return nil, nil
+		})
+	})
+
+	select {
+	case <-runner.stopped:
+	default:
+		t.Fatal("Expected the background runner to be stopped when the subtest completed")
+	}
+}
+
+func TestRunInBackgroundEvery(t *testing.T) {
+	t.Parallel()
+
+	interval := 150 * time.Millisecond
+
+	// The action takes most of the interval, which RunInBackground would add to the time between runs
+	runner := RunInBackgroundEvery(t, t.Name(), interval, func() (interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		return nil, nil
+	})
+
+	time.Sleep(4 * interval)
+	runner.Stop()
+
+	results := runner.Results()
+	assert.True(t, len(results) >= 3, "expected at least 3 runs, got %d", len(results))
+	for i := 1; i < len(results); i++ {
+		period := results[i].Start.Sub(results[i-1].Start)
+		assert.True(t, period >= interval && period < interval+75*time.Millisecond, "expected runs %s apart, got %s", interval, period)
+	}
+}
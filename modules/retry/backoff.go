@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// The longest sleep a Backoff can return, as longer ones overflow time.Duration
+const maxDuration = time.Duration(math.MaxInt64)
+
+// Backoff is a strategy for how long to sleep between retries.
+type Backoff interface {
+	// Duration returns how long to sleep before the given retry, where the first retry is 1, given how long the sleep
+	// before the previous retry was (zero before the first retry).
+	Duration(retry int, previous time.Duration) time.Duration
+}
+
+// ConstantBackoff sleeps for the same amount of time before every retry.
+type ConstantBackoff struct {
+	Sleep time.Duration // How long to sleep before every retry
+}
+
+// Duration returns how long to sleep before the given retry.
+func (backoff ConstantBackoff) Duration(retry int, previous time.Duration) time.Duration {
+	return backoff.Sleep
+}
+
+// ExponentialBackoff sleeps for Base before the first retry and multiplies the sleep by Multiplier before every retry
+// after that, up to a maximum of Max.
+type ExponentialBackoff struct {
+	Base       time.Duration // How long to sleep before the first retry
+	Max        time.Duration // The maximum amount of time to sleep before a retry. Zero means no maximum.
+	Multiplier float64       // How much to multiply the sleep by before every retry. Defaults to 2.
+}
+
+// Duration returns how long to sleep before the given retry.
+func (backoff ExponentialBackoff) Duration(retry int, previous time.Duration) time.Duration {
+	multiplier := backoff.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	sleep := float64(backoff.Base) * math.Pow(multiplier, float64(retry-1))
+	if backoff.Max > 0 && sleep > float64(backoff.Max) {
+		return backoff.Max
+	}
+	// Without a Max, the sleep grows past what a time.Duration can hold after enough retries, and converting it would
+	// overflow into a negative sleep, which time.Sleep returns from right away
+	if sleep >= float64(maxDuration) {
+		return maxDuration
+	}
+	return time.Duration(sleep)
+}
+
+// DecorrelatedJitterBackoff sleeps for a random amount of time between Base and three times the previous sleep, up to
+// a maximum of Max. The randomness spreads out the retries of many tests that hit the same API at the same time, such
+// as when waiting for an eventually consistent AWS resource. See
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/ for details.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration // The minimum amount of time to sleep before a retry
+	Max  time.Duration // The maximum amount of time to sleep before a retry. Zero means no maximum.
+}
+
+// Duration returns how long to sleep before the given retry.
+func (backoff DecorrelatedJitterBackoff) Duration(retry int, previous time.Duration) time.Duration {
+	upper := maxDuration
+	if previous < maxDuration/3 {
+		upper = previous * 3
+	}
+	if upper <= backoff.Base {
+		upper = backoff.Base * 3
+	}
+
+	sleep := backoff.Base
+	if upper > backoff.Base {
+		sleep += time.Duration(rand.Int63n(int64(upper - backoff.Base)))
+	}
+
+	if backoff.Max > 0 && sleep > backoff.Max {
+		return backoff.Max
+	}
+	return sleep
+}
@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := ConstantBackoff{Sleep: 5 * time.Second}
+	for retry := 1; retry < 5; retry++ {
+		assert.Equal(t, 5*time.Second, backoff.Duration(retry, 5*time.Second))
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := ExponentialBackoff{Base: time.Second, Max: 10 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, expectedSleep := range expected {
+		assert.Equal(t, expectedSleep, backoff.Duration(i+1, 0))
+	}
+
+	tripling := ExponentialBackoff{Base: time.Second, Multiplier: 3}
+	assert.Equal(t, 9*time.Second, tripling.Duration(3, 0))
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := DecorrelatedJitterBackoff{Base: time.Second, Max: 20 * time.Second}
+
+	var sleep time.Duration
+	for retry := 1; retry < 100; retry++ {
+		previous := sleep
+		sleep = backoff.Duration(retry, previous)
+
+		assert.True(t, sleep >= time.Second, "sleep %s is less than the base", sleep)
+		assert.True(t, sleep <= 20*time.Second, "sleep %s is more than the max", sleep)
+		if previous > time.Second {
+			assert.True(t, sleep <= previous*3, "sleep %s is more than three times the previous sleep %s", sleep, previous)
+		}
+	}
+}
+
+func TestBackoffWithoutMaxDoesNotOverflow(t *testing.T) {
+	t.Parallel()
+
+	exponential := ExponentialBackoff{Base: time.Second}
+	var previous time.Duration
+	for _, retry := range []int{30, 34, 35, 64, 1000} {
+		sleep := exponential.Duration(retry, previous)
+		assert.True(t, sleep >= previous, "sleep %s before retry %d is less than the previous sleep %s", sleep, retry, previous)
+		previous = sleep
+	}
+	assert.Equal(t, time.Duration(math.MaxInt64), exponential.Duration(35, 0))
+
+	jitter := DecorrelatedJitterBackoff{Base: time.Second}
+	sleep := jitter.Duration(100, time.Duration(math.MaxInt64))
+	assert.True(t, sleep >= time.Second, "sleep %s is less than the base", sleep)
+}
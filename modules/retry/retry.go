@@ -2,12 +2,12 @@
 package retry
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
-	"golang.org/x/net/context"
 )
 
 // Either contains a result and potentially an error.
@@ -62,11 +62,48 @@ func DoWithRetry(t *testing.T, actionDescription string, maxRetries int, sleepBe
 // immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
 // maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error.
 func DoWithRetryE(t *testing.T, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) (string, error) {
-	var output string
+	options := Options{MaxRetries: maxRetries, Backoff: ConstantBackoff{Sleep: sleepBetweenRetries}}
+
+	out, err := DoWithOptionsE(t, actionDescription, options, func() (interface{}, error) {
+		return action()
+	})
+
+	output, _ := out.(string)
+	return output, err
+}
+
+// Options configures how DoWithOptions retries an action.
+type Options struct {
+	MaxRetries int                          // The maximum number of retries, so the action runs at most MaxRetries + 1 times
+	Backoff    Backoff                      // How long to sleep between retries. Defaults to no sleep at all.
+	OnRetry    func(attempt int, err error) // If set, called with the number of the attempt that failed (starting at 1) and its error before every retry
+	Logger     logger.Logger                `json:"-"` // The Logger to log every attempt with. Defaults to the default Logger of the logger package.
+}
+
+// DoWithOptions runs the specified action. If it returns a value, return that value. If it returns a FatalError,
+// return that error immediately. If it returns any other type of error, sleep as long as the Backoff in the given
+// options says and try again, up to a maximum of MaxRetries retries. If MaxRetries is exceeded, fail the test. Unlike
+// DoWithRetry, the action can return a value of any type, so there is no need to smuggle results out through a
+// closure.
+func DoWithOptions(t *testing.T, actionDescription string, options Options, action func() (interface{}, error)) interface{} {
+	out, err := DoWithOptionsE(t, actionDescription, options, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithOptionsE runs the specified action. If it returns a value, return that value. If it returns a FatalError,
+// return that error immediately. If it returns any other type of error, sleep as long as the Backoff in the given
+// options says and try again, up to a maximum of MaxRetries retries. If MaxRetries is exceeded, return a
+// MaxRetriesExceeded error along with the value the action returned on its last attempt.
+func DoWithOptionsE(t *testing.T, actionDescription string, options Options, action func() (interface{}, error)) (interface{}, error) {
+	var output interface{}
 	var err error
+	var sleep time.Duration
 
-	for i := 0; i <= maxRetries; i++ {
-		logger.Log(t, actionDescription)
+	for i := 0; i <= options.MaxRetries; i++ {
+		logger.LogTo(t, options.Logger, logger.LevelInfo, actionDescription)
 
 		output, err = action()
 		if err == nil {
@@ -74,51 +111,50 @@ func DoWithRetryE(t *testing.T, actionDescription string, maxRetries int, sleepB
 		}
 
 		if _, isFatalErr := err.(FatalError); isFatalErr {
-			logger.Logf(t, "Returning due to fatal error: %v", err)
+			logger.LogfTo(t, options.Logger, logger.LevelInfo, "Returning due to fatal error: %v", err)
 			return output, err
 		}
 
-		logger.Logf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleepBetweenRetries)
-		time.Sleep(sleepBetweenRetries)
+		if i == options.MaxRetries {
+			break
+		}
+
+		if options.OnRetry != nil {
+			options.OnRetry(i+1, err)
+		}
+
+		if options.Backoff != nil {
+			sleep = options.Backoff.Duration(i+1, sleep)
+		}
+
+		logger.LogfTo(t, options.Logger, logger.LevelInfo, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleep)
+		time.Sleep(sleep)
 	}
 
-	return output, MaxRetriesExceeded{Description: actionDescription, MaxRetries: maxRetries}
+	logger.LogfTo(t, options.Logger, logger.LevelInfo, "%s returned an error: %s. Giving up after %d retries.", actionDescription, err.Error(), options.MaxRetries)
+	return output, MaxRetriesExceeded{Description: actionDescription, MaxRetries: options.MaxRetries}
 }
 
 // Done can be stopped.
 type Done struct {
-	stop chan bool
+	runner *BackgroundRunner
 }
 
-// Done stops the execution.
+// Done stops the execution and waits for the background goroutine to exit.
 func (done Done) Done() {
-	done.stop <- true
+	done.runner.Stop()
 }
 
 // DoInBackgroundUntilStopped runs the specified action in the background (in a goroutine) repeatedly, waiting the specified amount of time between
-// repetitions. To stop this action, call the Done() function on the returned value.
+// repetitions. To stop this action, call the Done() function on the returned value. To see what the action returns,
+// use RunInBackground instead.
 func DoInBackgroundUntilStopped(t *testing.T, actionDescription string, sleepBetweenRepeats time.Duration, action func()) Done {
-	stop := make(chan bool)
-
-	go func() {
-		for {
-			logger.Logf(t, "Executing action '%s'", actionDescription)
+	runner := RunInBackground(t, actionDescription, sleepBetweenRepeats, func() (interface{}, error) {
+		action()
+		return nil, nil
+	})
 
-			action()
-
-			logger.Logf(t, "Sleeping for %s before repeating action '%s'", sleepBetweenRepeats, actionDescription)
-
-			select {
-			case <-time.After(sleepBetweenRepeats):
-				// Nothing to do, just allow the loop to continue
-			case <-stop:
-				logger.Logf(t, "Received stop signal for action '%s'.", actionDescription)
-				return
-			}
-		}
-	}()
-
-	return Done{stop: stop}
+	return Done{runner: runner}
 }
 
 // Custom error types
@@ -127,20 +163,25 @@ func DoInBackgroundUntilStopped(t *testing.T, actionDescription string, sleepBet
 type TimeoutExceeded struct {
 	Description string
 	Timeout     time.Duration
+	Attempts    []Attempt // The attempts that failed before the timeout, if the action was retried
 }
 
 func (err TimeoutExceeded) Error() string {
-	return fmt.Sprintf("'%s' did not complete before timeout of %s", err.Description, err.Timeout)
+	if err.Timeout == 0 {
+		return fmt.Sprintf("'%s' was cancelled before it completed%s", err.Description, formatAttempts(err.Attempts))
+	}
+	return fmt.Sprintf("'%s' did not complete before timeout of %s%s", err.Description, err.Timeout, formatAttempts(err.Attempts))
 }
 
 // MaxRetriesExceeded is an error that occurs when the maximum amount of retries is exceeded.
 type MaxRetriesExceeded struct {
 	Description string
 	MaxRetries  int
+	Attempts    []Attempt // Every attempt that failed. Only set by DoWithRetryContextE.
 }
 
 func (err MaxRetriesExceeded) Error() string {
-	return fmt.Sprintf("'%s' unsuccessful after %d retries", err.Description, err.MaxRetries)
+	return fmt.Sprintf("'%s' unsuccessful after %d retries%s", err.Description, err.MaxRetries, formatAttempts(err.Attempts))
 }
 
 // FatalError is a marker interface for errors that should not be retried.
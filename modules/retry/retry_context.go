@@ -0,0 +1,159 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// Attempt is the record of a single attempt at running an action, as reported in MaxRetriesExceeded and
+// TimeoutExceeded errors from DoWithRetryContextE.
+type Attempt struct {
+	Number   int           // The number of the attempt, starting at 1
+	Start    time.Time     // When the attempt started
+	Duration time.Duration // How long the attempt took
+	Err      error         // The error the attempt returned
+}
+
+// DoWithTimeoutContext runs the specified action and waits up to the specified timeout, or until the given context is
+// done, for it to complete. The action gets a context that is cancelled when the timeout expires, so it can stop
+// whatever it's doing rather than running on in the background. Return the output of the action if it completes on
+// time or fail the test otherwise.
+func DoWithTimeoutContext(t *testing.T, ctx context.Context, actionDescription string, timeout time.Duration, action func(ctx context.Context) (interface{}, error)) interface{} {
+	out, err := DoWithTimeoutContextE(t, ctx, actionDescription, timeout, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithTimeoutContextE runs the specified action and waits up to the specified timeout, or until the given context is
+// done, for it to complete. The action gets a context that is cancelled when the timeout expires, so it can stop
+// whatever it's doing rather than running on in the background. Return the output of the action if it completes on
+// time or a TimeoutExceeded error otherwise.
+func DoWithTimeoutContextE(t *testing.T, ctx context.Context, actionDescription string, timeout time.Duration, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		out interface{}
+		err error
+	}
+
+	// The channel is buffered, so the goroutine can always send its result and exit, even if we've stopped waiting
+	resultChannel := make(chan result, 1)
+
+	go func() {
+		out, err := action(ctx)
+		resultChannel <- result{out: out, err: err}
+	}()
+
+	select {
+	case res := <-resultChannel:
+		return res.out, res.err
+	case <-ctx.Done():
+		return nil, TimeoutExceeded{Description: actionDescription, Timeout: timeout}
+	}
+}
+
+// DoWithRetryContext runs the specified action, passing it the given context. If it returns a value, return that
+// value. If it returns a FatalError, return that error immediately. If it returns any other type of error, sleep as
+// long as the Backoff in the given options says and try again, up to a maximum of MaxRetries retries. Unlike
+// DoWithOptions, the retries also stop once the context is done, so a context with a deadline (see
+// context.WithTimeout) sets a wall-clock budget across all attempts. If MaxRetries is exceeded or the context is done
+// first, fail the test.
+func DoWithRetryContext(t *testing.T, ctx context.Context, actionDescription string, options Options, action func(ctx context.Context) (interface{}, error)) interface{} {
+	out, err := DoWithRetryContextE(t, ctx, actionDescription, options, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithRetryContextE runs the specified action, passing it the given context. If it returns a value, return that
+// value. If it returns a FatalError, return that error immediately. If it returns any other type of error, sleep as
+// long as the Backoff in the given options says and try again, up to a maximum of MaxRetries retries. Unlike
+// DoWithOptionsE, the retries also stop once the context is done, so a context with a deadline (see
+// context.WithTimeout) sets a wall-clock budget across all attempts. If MaxRetries is exceeded, return a
+// MaxRetriesExceeded error, and if the context is done first, return a TimeoutExceeded error. Both errors include the
+// history of every attempt.
+func DoWithRetryContextE(t *testing.T, ctx context.Context, actionDescription string, options Options, action func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	var timeout time.Duration
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		timeout = time.Until(deadline)
+	}
+
+	var output interface{}
+	var sleep time.Duration
+	attempts := []Attempt{}
+
+	timeoutExceeded := func() (interface{}, error) {
+		logger.LogfTo(t, options.Logger, logger.LevelInfo, "%s did not complete before its context was done: %v", actionDescription, ctx.Err())
+		return output, TimeoutExceeded{Description: actionDescription, Timeout: timeout, Attempts: attempts}
+	}
+
+	for i := 0; i <= options.MaxRetries; i++ {
+		if ctx.Err() != nil {
+			return timeoutExceeded()
+		}
+
+		logger.LogTo(t, options.Logger, logger.LevelInfo, actionDescription)
+
+		start := time.Now()
+		var err error
+		output, err = action(ctx)
+		if err == nil {
+			return output, nil
+		}
+		attempts = append(attempts, Attempt{Number: i + 1, Start: start, Duration: time.Since(start), Err: err})
+
+		if _, isFatalErr := err.(FatalError); isFatalErr {
+			logger.LogfTo(t, options.Logger, logger.LevelInfo, "Returning due to fatal error: %v", err)
+			return output, err
+		}
+
+		if ctx.Err() != nil {
+			return timeoutExceeded()
+		}
+
+		if i == options.MaxRetries {
+			break
+		}
+
+		if options.OnRetry != nil {
+			options.OnRetry(i+1, err)
+		}
+
+		if options.Backoff != nil {
+			sleep = options.Backoff.Duration(i+1, sleep)
+		}
+
+		logger.LogfTo(t, options.Logger, logger.LevelInfo, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleep)
+
+		select {
+		case <-time.After(sleep):
+		case <-ctx.Done():
+			return timeoutExceeded()
+		}
+	}
+
+	logger.LogfTo(t, options.Logger, logger.LevelInfo, "%s returned an error on every attempt. Giving up after %d retries.", actionDescription, options.MaxRetries)
+	return output, MaxRetriesExceeded{Description: actionDescription, MaxRetries: options.MaxRetries, Attempts: attempts}
+}
+
+// Format the given attempts for an error message, or return an empty string if there are none
+func formatAttempts(attempts []Attempt) string {
+	if len(attempts) == 0 {
+		return ""
+	}
+
+	lines := []string{}
+	for _, attempt := range attempts {
+		lines = append(lines, fmt.Sprintf("  attempt %d at %s took %s: %v", attempt.Number, attempt.Start.Format(time.RFC3339), attempt.Duration, attempt.Err))
+	}
+	return fmt.Sprintf(". Attempts:\n%s", strings.Join(lines, "\n"))
+}
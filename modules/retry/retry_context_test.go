@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoWithTimeoutContext(t *testing.T) {
+	t.Parallel()
+
+	out := DoWithTimeoutContext(t, context.Background(), "Returns value immediately", 5*time.Second, func(ctx context.Context) (interface{}, error) {
+		return 42, nil
+	})
+	assert.Equal(t, 42, out)
+
+	actionStopped := make(chan bool, 1)
+	_, err := DoWithTimeoutContextE(t, context.Background(), "Blocks until cancelled", 100*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		actionStopped <- true
+		return nil, ctx.Err()
+	})
+	assert.Equal(t, TimeoutExceeded{Description: "Blocks until cancelled", Timeout: 100 * time.Millisecond}, err)
+
+	select {
+	case <-actionStopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Action was not cancelled after the timeout")
+	}
+}
+
+func TestDoWithRetryContextMaxRetriesExceeded(t *testing.T) {
+	t.Parallel()
+
+	expectedError := fmt.Errorf("expected error")
+	options := Options{MaxRetries: 2, Backoff: ConstantBackoff{Sleep: time.Millisecond}}
+
+	_, err := DoWithRetryContextE(t, context.Background(), "Always fail", options, func(ctx context.Context) (interface{}, error) {
+		return nil, expectedError
+	})
+
+	maxRetriesExceeded, isMaxRetriesExceeded := err.(MaxRetriesExceeded)
+	assert.True(t, isMaxRetriesExceeded)
+	assert.Equal(t, 2, maxRetriesExceeded.MaxRetries)
+	assert.Len(t, maxRetriesExceeded.Attempts, 3)
+	for i, attempt := range maxRetriesExceeded.Attempts {
+		assert.Equal(t, i+1, attempt.Number)
+		assert.Equal(t, expectedError, attempt.Err)
+	}
+	assert.Contains(t, err.Error(), "attempt 3")
+}
+
+func TestDoWithRetryContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	expectedError := fmt.Errorf("expected error")
+	options := Options{MaxRetries: 1000, Backoff: ConstantBackoff{Sleep: 100 * time.Millisecond}}
+
+	start := time.Now()
+	_, err := DoWithRetryContextE(t, ctx, "Fail until the deadline", options, func(ctx context.Context) (interface{}, error) {
+		return nil, expectedError
+	})
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	timeoutExceeded, isTimeoutExceeded := err.(TimeoutExceeded)
+	assert.True(t, isTimeoutExceeded)
+	assert.True(t, len(timeoutExceeded.Attempts) > 1)
+	assert.True(t, len(timeoutExceeded.Attempts) < 10)
+
+	out := DoWithRetryContext(t, context.Background(), "Succeed on the second attempt", options, func() func(ctx context.Context) (interface{}, error) {
+		attempts := 0
+		return func(ctx context.Context) (interface{}, error) {
+			attempts++
+			if attempts < 2 {
+				return nil, expectedError
+			}
+			return "done", nil
+		}
+	}())
+	assert.Equal(t, "done", out)
+}
@@ -130,3 +130,47 @@ func TestDoInBackgroundUntilStopped(t *testing.T) {
 	time.Sleep(sleepBetweenRetries * 3)
 	assert.Equal(t, 3, counter)
 }
+
+func TestDoWithOptions(t *testing.T) {
+	t.Parallel()
+
+	expectedError := fmt.Errorf("expected error")
+	type result struct {
+		attempts int
+	}
+
+	attempts := 0
+	retries := []int{}
+	options := Options{
+		MaxRetries: 5,
+		Backoff:    ExponentialBackoff{Base: time.Millisecond, Max: 2 * time.Millisecond},
+		OnRetry: func(attempt int, err error) {
+			assert.Equal(t, expectedError, err)
+			retries = append(retries, attempt)
+		},
+	}
+
+	out := DoWithOptions(t, "Return a struct after 3 attempts", options, func() (interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, expectedError
+		}
+		return result{attempts: attempts}, nil
+	})
+
+	assert.Equal(t, result{attempts: 3}, out)
+	assert.Equal(t, []int{1, 2}, retries)
+
+	_, err := DoWithOptionsE(t, "Always fail", Options{MaxRetries: 2}, func() (interface{}, error) {
+		return nil, expectedError
+	})
+	assert.Equal(t, MaxRetriesExceeded{Description: "Always fail", MaxRetries: 2}, err)
+
+	fatalAttempts := 0
+	_, err = DoWithOptionsE(t, "Fail fatally", Options{MaxRetries: 2}, func() (interface{}, error) {
+		fatalAttempts++
+		return nil, FatalError{Underlying: expectedError}
+	})
+	assert.Equal(t, FatalError{Underlying: expectedError}, err)
+	assert.Equal(t, 1, fatalAttempts)
+}
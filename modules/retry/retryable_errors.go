@@ -0,0 +1,100 @@
+package retry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// DoWithRetryableErrors runs the specified action and retries it, as DoWithRetry does, but only if it fails with one
+// of the given retryable errors. See DoWithRetryableErrorsE for details. If the action fails with any other error, or
+// maxRetries is exceeded, fail the test.
+func DoWithRetryableErrors(t *testing.T, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) string {
+	out, err := DoWithRetryableErrorsE(t, actionDescription, retryableErrors, maxRetries, sleepBetweenRetries, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithRetryableErrorsE runs the specified action and retries it, as DoWithRetryE does, but only if it fails with one
+// of the given retryable errors. The keys of retryableErrors are regular expressions that are matched against both the
+// output the action returns (e.g. the stdout and stderr of a command) and the text of its error, and the values are
+// messages to log when that error is found (e.g. an explanation of why the error is transient). Any other error is
+// treated as a FatalError, so it's returned right away. If maxRetries is exceeded, return a MaxRetriesExceeded error.
+// If one of the keys isn't a valid regular expression, return an InvalidRetryableErrorPattern error without running
+// the action at all. To match plain text that contains special characters, such as . or (, escape it with
+// regexp.QuoteMeta.
+func DoWithRetryableErrorsE(t *testing.T, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) (string, error) {
+	options := Options{MaxRetries: maxRetries, Backoff: ConstantBackoff{Sleep: sleepBetweenRetries}}
+	return DoWithRetryableErrorsAndOptionsE(t, actionDescription, retryableErrors, options, action)
+}
+
+// DoWithRetryableErrorsAndOptions runs the specified action and retries it, as DoWithOptions does, but only if it fails
+// with one of the given retryable errors. See DoWithRetryableErrorsE for how the errors are matched. If the action
+// fails with any other error, or MaxRetries is exceeded, fail the test.
+func DoWithRetryableErrorsAndOptions(t *testing.T, actionDescription string, retryableErrors map[string]string, options Options, action func() (string, error)) string {
+	out, err := DoWithRetryableErrorsAndOptionsE(t, actionDescription, retryableErrors, options, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithRetryableErrorsAndOptionsE runs the specified action and retries it, as DoWithOptionsE does, but only if it
+// fails with one of the given retryable errors. See DoWithRetryableErrorsE for how the errors are matched. Unlike
+// DoWithRetryableErrorsE, the given options can also set the Backoff, an OnRetry hook, and the Logger to log every
+// attempt with.
+func DoWithRetryableErrorsAndOptionsE(t *testing.T, actionDescription string, retryableErrors map[string]string, options Options, action func() (string, error)) (string, error) {
+	patterns := make([]string, 0, len(retryableErrors))
+	for pattern := range retryableErrors {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", InvalidRetryableErrorPattern{Pattern: pattern, Underlying: err}
+		}
+		regexes = append(regexes, regex)
+	}
+
+	out, err := DoWithOptionsE(t, actionDescription, options, func() (interface{}, error) {
+		out, err := action()
+		if err == nil {
+			return out, nil
+		}
+		if _, isFatalErr := err.(FatalError); isFatalErr {
+			return out, err
+		}
+
+		for i, regex := range regexes {
+			if regex.MatchString(out) || regex.MatchString(err.Error()) {
+				logger.LogfTo(t, options.Logger, logger.LevelInfo, "'%s' failed with the error '%s' but this error was expected and warrants a retry. Further details: %s", actionDescription, patterns[i], retryableErrors[patterns[i]])
+				return out, err
+			}
+		}
+
+		return out, FatalError{Underlying: err}
+	})
+
+	output, _ := out.(string)
+	return output, err
+}
+
+// InvalidRetryableErrorPattern is an error that occurs when a key of the retryable errors given to
+// DoWithRetryableErrors is not a valid regular expression.
+type InvalidRetryableErrorPattern struct {
+	Pattern    string
+	Underlying error
+}
+
+func (err InvalidRetryableErrorPattern) Error() string {
+	return fmt.Sprintf("Retryable error '%s' is not a valid regular expression: %v. To match it as plain text, escape it with regexp.QuoteMeta.", err.Pattern, err.Underlying)
+}
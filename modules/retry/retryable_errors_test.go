@@ -0,0 +1,92 @@
+package retry
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoWithRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	retryableErrors := map[string]string{
+		"TLS handshake timeout":     "Transient network error",
+		`(?i)throttl(ed|ing)`:       "AWS rate limit",
+		`connection reset by peer$`: "Flaky connection",
+	}
+
+	createActionThatFailsOnce := func(out string, err error) func() (string, error) {
+		attempts := 0
+		return func() (string, error) {
+			attempts++
+			if attempts == 1 {
+				return out, err
+			}
+			return "success", nil
+		}
+	}
+
+	testCases := []struct {
+		description   string
+		action        func() (string, error)
+		expectedOut   string
+		expectedFatal bool
+	}{
+		{"Retry error in output", createActionThatFailsOnce("Error: net/http: TLS handshake timeout", errors.New("exit status 1")), "success", false},
+		{"Retry error in error text", createActionThatFailsOnce("", errors.New("read tcp: connection reset by peer")), "success", false},
+		{"Retry case insensitive match", createActionThatFailsOnce("Request was THROTTLED", errors.New("exit status 1")), "success", false},
+		{"Do not retry other errors", createActionThatFailsOnce("Error: invalid syntax", errors.New("exit status 1")), "Error: invalid syntax", true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase // capture range variable for each test case
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := DoWithRetryableErrorsE(t, testCase.description, retryableErrors, 3, time.Millisecond, testCase.action)
+			assert.Equal(t, testCase.expectedOut, out)
+			if testCase.expectedFatal {
+				assert.Equal(t, FatalError{Underlying: errors.New("exit status 1")}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDoWithRetryableErrorsInvalidRegex(t *testing.T) {
+	t.Parallel()
+
+	_, err := DoWithRetryableErrorsE(t, "Invalid regex", map[string]string{"(": "Not a regex"}, 3, time.Millisecond, func() (string, error) {
+		return "", nil
+	})
+	if assert.IsType(t, InvalidRetryableErrorPattern{}, err) {
+		assert.Equal(t, "(", err.(InvalidRetryableErrorPattern).Pattern)
+	}
+	assert.Contains(t, err.Error(), "Retryable error '(' is not a valid regular expression")
+}
+
+func TestDoWithRetryableErrorsLogsToGivenLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	attempts := 0
+	options := Options{MaxRetries: 3, Logger: logger.NewWriterLogger(&buffer)}
+	out, err := DoWithRetryableErrorsAndOptionsE(t, "Log to given logger", map[string]string{"flaky": "Flaky test"}, options, func() (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "flaky", errors.New("exit status 1")
+		}
+		return "success", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "success", out)
+	assert.Contains(t, buffer.String(), "'Log to given logger' failed with the error 'flaky'")
+	assert.Contains(t, buffer.String(), "Log to given logger returned an error: exit status 1")
+}
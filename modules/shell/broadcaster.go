@@ -0,0 +1,24 @@
+package shell
+
+// broadcaster wakes up every goroutine waiting for some shared state, such as the output of a command, to change. It
+// has no lock of its own, so all its methods must be called with the lock that guards that state held. The zero value
+// is ready to use.
+type broadcaster struct {
+	changed chan struct{} // Closed, and set back to nil, the next time the state changes
+}
+
+// Return a channel that is closed the next time the state changes
+func (b *broadcaster) wait() <-chan struct{} {
+	if b.changed == nil {
+		b.changed = make(chan struct{})
+	}
+	return b.changed
+}
+
+// Wake up everyone waiting for the state to change
+func (b *broadcaster) notify() {
+	if b.changed != nil {
+		close(b.changed)
+		b.changed = nil
+	}
+}
@@ -1,27 +1,47 @@
 package shell
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/gruntwork-io/terratest/modules/logger"
 )
 
 // Command is a simpler struct for defining commands than Go's built-in Cmd.
 type Command struct {
-	Command    string            // The command to run
-	Args       []string          // The args to pass to the command
-	WorkingDir string            // The working directory
-	Env        map[string]string // Additional environment variables to set
+	Command          string            // The command to run
+	Args             []string          // The args to pass to the command
+	WorkingDir       string            // The working directory
+	Env              map[string]string // Additional environment variables to set
+	Quiet            bool              // If true, the command's stdout and stderr are captured, but not logged
+	Timeout          time.Duration     // If set, the command is stopped if it runs for longer than this. See RunCommandAndGetOutputContextE.
+	KillGracePeriod  time.Duration     // How long to wait after sending SIGTERM to a command that timed out before sending SIGKILL. Defaults to DefaultKillGracePeriod.
+	SuccessExitCodes []int             // Non-zero exit codes to treat as success rather than as an error (e.g., 2 for terraform plan -detailed-exitcode)
+	OutputWriter     io.Writer         // If set, the raw stdout and stderr of the command, with secrets masked, are also written to this writer as they are read
+	OutputFile       string            // If set, the raw stdout and stderr of the command, with secrets masked, are also written to this file, which is created or truncated
+	Stdin            io.Reader         // If set, the command reads its stdin from this reader. If neither this nor StdinString is set, the command reads the stdin of this Go program.
+	StdinString      string            // If set, and Stdin is not, the command reads this string as its stdin
+	CleanEnv         bool              // If true, the command does not inherit the environment of this Go program, other than the variables in InheritEnv
+	InheritEnv       []string          // The names of the environment variables to inherit from this Go program when CleanEnv is true (e.g., PATH, HOME)
+	Secrets          []string          // Values, such as passwords or tokens, to mask in the log output of the command, in its OutputWriter and OutputFile, and in the "Running command" log line
+	Container        *Container        // If set, the command runs in this Docker container rather than directly on this machine
+	Logger           logger.Logger     `json:"-"` // The Logger to log the command and its output with. Defaults to the default Logger of the logger package.
+	LogModule        string            // The Terratest module running the command (e.g., terraform), which is logged as the Module of every log entry about the command and its output. Defaults to the module that called this one, worked out from the stack.
 }
 
+// DefaultKillGracePeriod is how long to wait after sending SIGTERM to a command that timed out before sending it
+// SIGKILL, if the Command doesn't specify a KillGracePeriod.
+const DefaultKillGracePeriod = 10 * time.Second
+
 // RunCommand runs a shell command and redirects its stdout and stderr to the stdout of the atomic script itself.
 func RunCommand(t *testing.T, command Command) {
 	err := RunCommandE(t, command)
@@ -49,70 +69,108 @@ func RunCommandAndGetOutput(t *testing.T, command Command) string {
 // RunCommandAndGetOutputE runs a shell command and returns its stdout and stderr as a string. The stdout and stderr of that command will also
 // be printed to the stdout and stderr of this Go program to make debugging easier.
 func RunCommandAndGetOutputE(t *testing.T, command Command) (string, error) {
-	logger.Logf(t, "Running command %s with args %s", command.Command, command.Args)
-
-	cmd := exec.Command(command.Command, command.Args...)
-	cmd.Dir = command.WorkingDir
-	cmd.Stdin = os.Stdin
-	cmd.Env = formatEnvVars(command)
+	return RunCommandAndGetOutputContextE(t, context.Background(), command)
+}
 
-	stdout, err := cmd.StdoutPipe()
+// RunCommandAndGetOutputContext runs a shell command, stopping it if the given context is done or the command's Timeout
+// expires, and returns its stdout and stderr as a string. See RunCommandAndGetOutputContextE for details.
+func RunCommandAndGetOutputContext(t *testing.T, ctx context.Context, command Command) string {
+	out, err := RunCommandAndGetOutputContextE(t, ctx, command)
 	if err != nil {
-		return "", err
+		t.Fatal(err)
 	}
+	return out
+}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return "", err
-	}
+// RunCommandAndGetOutputContextE runs a shell command and returns its stdout and stderr as a string. The stdout and
+// stderr of that command will also be printed to the stdout and stderr of this Go program to make debugging easier.
+//
+// If the given context is done, or the command's Timeout expires, before the command completes, the command is
+// stopped and this method returns a TimeoutExceeded error that contains the output captured so far. To stop the
+// command, we send SIGTERM to its entire process group, so that any processes it started (e.g., the plugins run by
+// terraform or packer) are stopped too, and then SIGKILL if it's still running after the KillGracePeriod. Note that
+// this means a command that can be cancelled runs in its own process group, so it won't receive signals (e.g., Ctrl+C)
+// sent to the process group of this Go program.
+func RunCommandAndGetOutputContextE(t *testing.T, ctx context.Context, command Command) (string, error) {
+	result, err := RunCommandAndGetResultContextE(t, ctx, command)
+	return result.Combined, err
+}
 
-	err = cmd.Start()
-	if err != nil {
-		return "", err
-	}
+// Result is the result of running a Command.
+type Result struct {
+	Stdout   string // Everything the command wrote to stdout
+	Stderr   string // Everything the command wrote to stderr
+	Combined string // Everything the command wrote to stdout and stderr, in the order it was written
+	ExitCode int    // The exit code of the command
+}
 
-	output, err := readStdoutAndStderr(t, stdout, stderr)
+// RunCommandAndGetResult runs a shell command and returns its stdout, stderr, and exit code. The stdout and stderr of
+// that command will also be printed to the stdout and stderr of this Go program to make debugging easier. If the
+// command exits with a non-zero exit code that is not in its SuccessExitCodes, fail the test.
+func RunCommandAndGetResult(t *testing.T, command Command) Result {
+	result, err := RunCommandAndGetResultE(t, command)
 	if err != nil {
-		return output, err
-	}
-
-	if err := cmd.Wait(); err != nil {
-		return output, err
+		t.Fatal(err)
 	}
+	return result
+}
 
-	return output, nil
+// RunCommandAndGetResultE runs a shell command and returns its stdout, stderr, and exit code. The stdout and stderr of
+// that command will also be printed to the stdout and stderr of this Go program to make debugging easier. If the
+// command exits with a non-zero exit code that is not in its SuccessExitCodes, return an error, along with the
+// result.
+func RunCommandAndGetResultE(t *testing.T, command Command) (Result, error) {
+	return RunCommandAndGetResultContextE(t, context.Background(), command)
 }
 
-// This function captures stdout and stderr while still printing it to the stdout and stderr of this Go program
-func readStdoutAndStderr(t *testing.T, stdout io.ReadCloser, stderr io.ReadCloser) (string, error) {
-	allOutput := []string{}
+// RunCommandAndGetResultContextE runs a shell command and returns its stdout, stderr, and exit code, stopping the
+// command if the given context is done or the command's Timeout expires. See RunCommandAndGetOutputContextE and
+// RunCommandAndGetResultE for details.
+func RunCommandAndGetResultContextE(t *testing.T, ctx context.Context, command Command) (Result, error) {
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
+	}
 
-	stdoutScanner := bufio.NewScanner(stdout)
-	stderrScanner := bufio.NewScanner(stderr)
+	// A context that can never be done (e.g., context.Background()) has a nil Done channel, in which case there is no
+	// need to change how the command handles signals
+	process, err := startProcessE(t, command, ctx.Done() != nil)
+	if err != nil {
+		return Result{}, err
+	}
 
-	for {
-		if stdoutScanner.Scan() {
-			text := stdoutScanner.Text()
-			logger.Log(t, text)
-			allOutput = append(allOutput, text)
-		} else if stderrScanner.Scan() {
-			text := stderrScanner.Text()
-			logger.Log(t, text)
-			allOutput = append(allOutput, text)
-		} else {
-			break
+	select {
+	case <-process.done:
+		return process.result, process.err
+	case <-ctx.Done():
+		if err := process.stop("did not complete in time"); err != nil {
+			logger.LogfToModule(t, command.Logger, command.LogModule, logger.LevelWarn, "%v", err)
 		}
+		return process.result, TimeoutExceeded{Command: command.Command, Args: command.Args, Timeout: command.Timeout, Output: process.result.Combined, Cause: ctx.Err()}
 	}
+}
 
-	if err := stdoutScanner.Err(); err != nil {
-		return "", err
-	}
+// Convert the given output and error from running the given command into a Result. If the command exited with one of
+// its SuccessExitCodes, the error is dropped.
+func toResult(command Command, out *output, err error) (Result, error) {
+	exitCode, exitCodeErr := GetExitCodeForRunCommandError(err)
 
-	if err := stderrScanner.Err(); err != nil {
-		return "", err
+	result := Result{
+		Stdout:   out.stdout(),
+		Stderr:   out.stderr(),
+		Combined: out.combined(),
+		ExitCode: exitCode,
 	}
 
-	return strings.Join(allOutput, "\n"), nil
+	if exitCodeErr == nil && exitCode != 0 {
+		for _, successExitCode := range command.SuccessExitCodes {
+			if exitCode == successExitCode {
+				return result, nil
+			}
+		}
+	}
+	return result, err
 }
 
 // GetExitCodeForRunCommandError tries to read the exit code for the error object returned from running a shell command. This is a bit tricky to do
@@ -135,10 +193,86 @@ func GetExitCodeForRunCommandError(err error) (int, error) {
 	return 0, nil
 }
 
+// Return the environment for the given command. By default, that's the environment of this Go program plus the Env of
+// the command, but if CleanEnv is set, only the variables in InheritEnv are passed through, so credentials and other
+// settings of the developer or CI machine don't silently leak into the command.
 func formatEnvVars(command Command) []string {
 	env := os.Environ()
+	if command.CleanEnv {
+		env = []string{}
+		for _, key := range command.InheritEnv {
+			if value, isSet := os.LookupEnv(key); isSet {
+				env = append(env, fmt.Sprintf("%s=%s", key, value))
+			}
+		}
+	}
+
 	for key, value := range command.Env {
 		env = append(env, fmt.Sprintf("%s=%s", key, value))
 	}
 	return env
 }
+
+// exec.Command looks up commands in the PATH of this Go program, so if the given environment sets a different PATH
+// (e.g., to put stub executables first), look up the given command in that PATH instead. If it can't be found there,
+// return it unchanged and let exec.Command look it up as usual.
+func lookPath(name string, env []string) string {
+	if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		return name
+	}
+
+	path := os.Getenv("PATH")
+	for _, keyValue := range env {
+		if strings.HasPrefix(keyValue, "PATH=") {
+			path = strings.TrimPrefix(keyValue, "PATH=")
+		}
+	}
+	if path == os.Getenv("PATH") {
+		return name
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+		candidate := filepath.Join(dir, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return candidate
+		}
+	}
+	return name
+}
+
+// Return the reader the given command should use as its stdin
+func formatStdin(command Command) io.Reader {
+	if command.Stdin != nil {
+		return command.Stdin
+	}
+	if command.StdinString != "" {
+		return strings.NewReader(command.StdinString)
+	}
+	return os.Stdin
+}
+
+// TimeoutExceeded is an error that occurs when a command is stopped because its Timeout expired or its context was
+// done. Output contains everything the command wrote to stdout and stderr before it was stopped.
+type TimeoutExceeded struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+	Output  string
+	Cause   error // Why the context was done: context.DeadlineExceeded if the Timeout or a deadline expired, or context.Canceled
+}
+
+func (err TimeoutExceeded) Error() string {
+	switch err.Cause {
+	case context.Canceled:
+		return fmt.Sprintf("command %s %v was stopped as its context was cancelled", err.Command, err.Args)
+	case context.DeadlineExceeded:
+		if err.Timeout > 0 {
+			return fmt.Sprintf("command %s %v was stopped as it did not complete before timeout of %s", err.Command, err.Args, err.Timeout)
+		}
+		return fmt.Sprintf("command %s %v was stopped as it did not complete before the deadline of its context", err.Command, err.Args)
+	}
+	return fmt.Sprintf("command %s %v was stopped as it did not complete before its context was done: %v", err.Command, err.Args, err.Cause)
+}
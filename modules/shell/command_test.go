@@ -1,9 +1,18 @@
 package shell
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,3 +28,323 @@ func TestRunCommandAndGetOutput(t *testing.T) {
 	out := RunCommandAndGetOutput(t, cmd)
 	assert.Equal(t, text, strings.TrimSpace(out))
 }
+
+func TestRunCommandAndGetOutputWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", "echo started; sleep 30; echo finished"},
+		Timeout: 500 * time.Millisecond,
+	}
+
+	start := time.Now()
+	out, err := RunCommandAndGetOutputE(t, cmd)
+
+	assert.True(t, time.Since(start) < 10*time.Second, "Command should have been stopped after its timeout")
+	assert.IsType(t, TimeoutExceeded{}, err)
+	assert.Equal(t, "started", out)
+	assert.Equal(t, "started", err.(TimeoutExceeded).Output)
+}
+
+func TestRunCommandAndGetOutputWithTimeoutIgnoringSigterm(t *testing.T) {
+	t.Parallel()
+
+	// Ignored signals are inherited, so the sleep process ignores SIGTERM too and has to be killed with SIGKILL
+	cmd := Command{
+		Command:         "sh",
+		Args:            []string{"-c", "trap '' TERM; echo started; sleep 30"},
+		Timeout:         500 * time.Millisecond,
+		KillGracePeriod: 500 * time.Millisecond,
+	}
+
+	start := time.Now()
+	out, err := RunCommandAndGetOutputE(t, cmd)
+
+	assert.True(t, time.Since(start) < 10*time.Second, "Command should have been killed after the grace period")
+	assert.IsType(t, TimeoutExceeded{}, err)
+	assert.Equal(t, "started", out)
+}
+
+func TestRunCommandAndGetOutputContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	cmd := Command{
+		Command: "sleep",
+		Args:    []string{"30"},
+	}
+
+	_, err := RunCommandAndGetOutputContextE(t, ctx, cmd)
+	assert.Equal(t, context.Canceled, err.(TimeoutExceeded).Cause)
+}
+
+func TestTimeoutExceededError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      TimeoutExceeded
+		expected string
+	}{
+		{"timeout", TimeoutExceeded{Command: "sleep", Args: []string{"30"}, Timeout: time.Second, Cause: context.DeadlineExceeded}, "command sleep [30] was stopped as it did not complete before timeout of 1s"},
+		{"deadline", TimeoutExceeded{Command: "sleep", Args: []string{"30"}, Cause: context.DeadlineExceeded}, "command sleep [30] was stopped as it did not complete before the deadline of its context"},
+		{"cancelled with timeout", TimeoutExceeded{Command: "sleep", Args: []string{"30"}, Timeout: time.Second, Cause: context.Canceled}, "command sleep [30] was stopped as its context was cancelled"},
+		{"cancelled", TimeoutExceeded{Command: "sleep", Args: []string{"30"}, Cause: context.Canceled}, "command sleep [30] was stopped as its context was cancelled"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.err.Error(), testCase.name)
+	}
+}
+
+func TestRunCommandAndGetResultSeparatesStreams(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", "echo out1; sleep 0.1; echo err1 1>&2; sleep 0.1; echo out2; exit 3"},
+	}
+
+	result, err := RunCommandAndGetResultE(t, cmd)
+
+	assert.Error(t, err)
+	assert.Equal(t, "out1\nout2", result.Stdout)
+	assert.Equal(t, "err1", result.Stderr)
+	assert.Equal(t, "out1\nerr1\nout2", result.Combined)
+	assert.Equal(t, 3, result.ExitCode)
+}
+
+func TestRunCommandAndGetResultWithSuccessExitCodes(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command:          "sh",
+		Args:             []string{"-c", "echo changes; exit 2"},
+		SuccessExitCodes: []int{2},
+	}
+
+	result := RunCommandAndGetResult(t, cmd)
+	assert.Equal(t, 2, result.ExitCode)
+	assert.Equal(t, "changes", result.Stdout)
+
+	cmd.SuccessExitCodes = []int{1}
+	_, err := RunCommandAndGetResultE(t, cmd)
+	assert.Error(t, err)
+}
+
+func TestRunCommandAndGetResultWithLongLines(t *testing.T) {
+	t.Parallel()
+
+	// bufio.Scanner fails on lines longer than 64KB, which big JSON plans can easily exceed
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", "head -c 200000 /dev/zero | tr '\\0' 'a'; echo; echo done"},
+		Quiet:   true,
+	}
+
+	result := RunCommandAndGetResult(t, cmd)
+	assert.Equal(t, strings.Repeat("a", 200000)+"\ndone", result.Stdout)
+}
+
+func TestRunCommandAndGetResultTeesOutput(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := ioutil.TempFile("", t.Name())
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	var buffer bytes.Buffer
+	cmd := Command{
+		Command:      "sh",
+		Args:         []string{"-c", "printf 'line1\\r\\n'; printf 'no-newline' 1>&2"},
+		OutputWriter: &buffer,
+		OutputFile:   tmpFile.Name(),
+	}
+
+	result := RunCommandAndGetResult(t, cmd)
+	assert.Equal(t, "line1", result.Stdout)
+	assert.Equal(t, "no-newline", result.Stderr)
+
+	fileContents, err := ioutil.ReadFile(tmpFile.Name())
+	assert.NoError(t, err)
+
+	for _, raw := range []string{buffer.String(), string(fileContents)} {
+		assert.Contains(t, raw, "line1\r\n")
+		assert.Contains(t, raw, "no-newline")
+	}
+}
+
+func TestRunCommandAndGetResultDoesNotDeadlockOnFullStderr(t *testing.T) {
+	t.Parallel()
+
+	// Write far more to stderr than fits in a pipe buffer before writing anything to stdout
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", "head -c 1000000 /dev/zero | tr '\\0' 'e' 1>&2; echo out"},
+		Quiet:   true,
+		Timeout: 30 * time.Second,
+	}
+
+	result := RunCommandAndGetResult(t, cmd)
+	assert.Equal(t, "out", result.Stdout)
+	assert.Len(t, result.Stderr, 1000000)
+}
+
+func TestStartCommandWaitForOutputAndKill(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command:         "sh",
+		Args:            []string{"-c", "echo starting; sleep 1; echo 'listening on port 8080' 1>&2; sleep 60"},
+		KillGracePeriod: 5 * time.Second,
+	}
+
+	process := StartCommand(t, cmd)
+
+	line, err := process.WaitForOutput(regexp.MustCompile(`port (\d+)`), 30*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "listening on port 8080", line)
+	assert.False(t, process.Exited())
+
+	_, err = process.WaitForOutput(regexp.MustCompile("never printed"), 100*time.Millisecond)
+	assert.Equal(t, OutputNotFound{Command: "sh", Pattern: "never printed", Timeout: 100 * time.Millisecond}, err)
+
+	start := time.Now()
+	assert.NoError(t, process.Kill())
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.True(t, process.Exited())
+
+	result, err := process.Wait()
+	assert.Error(t, err)
+	assert.Equal(t, "starting", result.Stdout)
+	assert.Equal(t, "listening on port 8080", result.Stderr)
+}
+
+func TestStartCommandReadStdout(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", "echo one; sleep 1; echo two; echo err 1>&2"},
+	}
+
+	process := StartCommand(t, cmd)
+
+	stdout, err := ioutil.ReadAll(process.Stdout())
+	assert.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(stdout))
+
+	stderr, err := ioutil.ReadAll(process.Stderr())
+	assert.NoError(t, err)
+	assert.Equal(t, "err\n", string(stderr))
+
+	result, err := process.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+
+	_, err = process.WaitForOutput(regexp.MustCompile("missing"), 30*time.Second)
+	assert.Equal(t, OutputNotFound{Command: "sh", Pattern: "missing", Timeout: 30 * time.Second, Exited: true}, err)
+}
+
+func TestRunCommandWithStdin(t *testing.T) {
+	t.Parallel()
+
+	fromString := RunCommandAndGetOutput(t, Command{Command: "cat", StdinString: "from a string"})
+	assert.Equal(t, "from a string", fromString)
+
+	fromReader := RunCommandAndGetOutput(t, Command{Command: "cat", Stdin: strings.NewReader("from a reader\n")})
+	assert.Equal(t, "from a reader", fromReader)
+}
+
+func TestRunCommandWithCleanEnv(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command:    "env",
+		CleanEnv:   true,
+		InheritEnv: []string{"PATH", "TERRATEST_VAR_THAT_IS_NOT_SET"},
+		Env:        map[string]string{"FOO": "bar"},
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	lines := strings.Split(out, "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines, "FOO=bar")
+	assert.Contains(t, lines, "PATH="+os.Getenv("PATH"))
+}
+
+func TestRunCommandWithSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := "terratest-shell-secret-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	cmd := Command{
+		Command: "echo",
+		Args:    []string{secret},
+		Secrets: []string{secret},
+	}
+
+	// The output itself isn't masked, so tests can still check it, but everything logged is
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, secret, out)
+	assert.Equal(t, "Running command echo with args [<sensitive>]", logger.Redact("Running command echo with args ["+secret+"]"))
+}
+
+func TestRunCommandWithSecretsMasksOutputWriter(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	secret := "terratest-shell-tee-secret-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	cmd := Command{
+		Command:      "echo",
+		Args:         []string{"token=" + secret},
+		Secrets:      []string{secret},
+		OutputWriter: &buffer,
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, "token="+secret, out)
+	assert.Equal(t, "token=<sensitive>\n", buffer.String())
+}
+
+func TestRunCommandWithLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", "echo line1; echo line2 1>&2"},
+		Logger:  logger.NewWriterLogger(&buffer),
+	}
+
+	RunCommand(t, cmd)
+
+	assert.Contains(t, buffer.String(), "Running command sh")
+	assert.Contains(t, buffer.String(), ": line1\n")
+	assert.Contains(t, buffer.String(), ": line2\n")
+}
+
+func TestRunCommandWithLogModule(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	cmd := Command{
+		Command:   "sh",
+		Args:      []string{"-c", "echo line1; echo line2 1>&2"},
+		Logger:    logger.NewJSONLogger(&buffer),
+		LogModule: "terraform",
+	}
+
+	RunCommand(t, cmd)
+
+	// The output is logged from goroutines, which aren't on the stack of the caller, so the module must be passed along
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 3)
+	for _, line := range lines {
+		var entry map[string]string
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "terraform", entry["module"], line)
+	}
+}
@@ -0,0 +1,177 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+// Container is the Docker container to run a Command in, so the command can use a pinned toolchain image (e.g.
+// hashicorp/terraform:0.11.7) rather than whatever binaries happen to be installed on the machine running the tests.
+// Set either Image, to run the command in a new container with docker run, or ID, to run the command in an existing
+// container with docker exec. With docker run, the working dir of the command, and the folder of every arg that is the
+// path of an existing file or folder (e.g. ../templates/build.json or -var-file=/tmp/test.tfvars), are mounted into
+// the container at the same paths, so those args work as is. Use Mounts for any other files the command needs. With
+// docker exec, nothing can be mounted, so the files must already be in the container.
+type Container struct {
+	Image      string            // The image to run the command in with docker run. The container is removed once the command exits.
+	ID         string            // The ID or name of a running container to run the command in with docker exec
+	Mounts     map[string]string // Extra folders to mount into a container started from Image, as a map of host path to container path
+	DockerArgs []string          // Extra args to pass to docker run or docker exec (e.g. --network host or --user 1000)
+}
+
+// Convert the given command, which has a Container, into the docker command that runs it in that container, and
+// return the name of the container, if the docker command starts a new one. With docker run, the container runs with
+// --init, so signals reach the command, and has a unique name, so it can be killed with docker kill if the docker
+// command itself has to be killed. The working dir of the command (or of this Go program, if it has none) and the
+// folders of its file args are mounted into the container at the same paths, so absolute and relative paths in the
+// args of the command work as is. The env vars of the command are forwarded by name only, so their values, which may
+// be secrets, never show up in the args of the docker command.
+func dockerCommand(command Command) (Command, string, error) {
+	container := command.Container
+	if (container.Image == "") == (container.ID == "") {
+		return Command{}, "", InvalidContainer{Image: container.Image, ID: container.ID}
+	}
+
+	workingDir := command.WorkingDir
+	if workingDir == "" {
+		var err error
+		if workingDir, err = os.Getwd(); err != nil {
+			return Command{}, "", err
+		}
+	}
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return Command{}, "", err
+	}
+
+	var args []string
+	var containerName string
+	if container.Image != "" {
+		containerName = fmt.Sprintf("terratest-%s", strings.ToLower(random.UniqueId()))
+		args = []string{"run", "--rm", "--init", "--name", containerName, "--entrypoint", command.Command}
+		for _, dir := range append([]string{workingDir}, argDirs(command.Args, workingDir)...) {
+			args = append(args, "-v", fmt.Sprintf("%s:%s", dir, dir))
+		}
+		for _, hostPath := range sortedKeys(container.Mounts) {
+			absHostPath, err := filepath.Abs(hostPath)
+			if err != nil {
+				return Command{}, "", err
+			}
+			args = append(args, "-v", fmt.Sprintf("%s:%s", absHostPath, container.Mounts[hostPath]))
+		}
+	} else {
+		args = []string{"exec"}
+	}
+
+	args = append(args, "-w", workingDir)
+	if command.Stdin != nil || command.StdinString != "" {
+		args = append(args, "-i")
+	}
+	for _, key := range sortedKeys(command.Env) {
+		args = append(args, "-e", key)
+	}
+	args = append(args, container.DockerArgs...)
+
+	if container.Image != "" {
+		args = append(args, container.Image)
+	} else {
+		args = append(args, container.ID, command.Command)
+	}
+	args = append(args, command.Args...)
+
+	dockerCommand := command
+	dockerCommand.Command = "docker"
+	dockerCommand.Args = args
+	dockerCommand.WorkingDir = ""
+	dockerCommand.Container = nil
+	return dockerCommand, containerName, nil
+}
+
+// Return the folders, outside the given working dir, of the given args that are paths of existing files or folders,
+// either on their own or as the value of a flag (e.g. -var-file=../test.tfvars). Relative paths are relative to the
+// working dir. A folder inside another folder in the list is left out, as mounting the outer one is enough.
+func argDirs(args []string, workingDir string) []string {
+	candidates := []string{}
+	for _, arg := range args {
+		candidates = append(candidates, arg)
+		if strings.HasPrefix(arg, "-") && strings.Contains(arg, "=") {
+			candidates = append(candidates, arg[strings.Index(arg, "=")+1:])
+		}
+	}
+
+	dirs := []string{}
+	for _, candidate := range candidates {
+		if candidate == "" || strings.HasPrefix(candidate, "-") {
+			continue
+		}
+		path := candidate
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			path = filepath.Dir(path)
+		}
+		dirs = append(dirs, filepath.Clean(path))
+	}
+	sort.Strings(dirs)
+
+	result := []string{}
+	for _, dir := range dirs {
+		// Never mount the root of the file system, e.g. for an arg of /
+		if isInDir(dir, workingDir) || filepath.Dir(dir) == dir {
+			continue
+		}
+		if len(result) > 0 && isInDir(dir, result[len(result)-1]) {
+			continue
+		}
+		result = append(result, dir)
+	}
+	return result
+}
+
+// Return true if the given path is the given folder or is inside it
+func isInDir(path string, dir string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// Kill the container with the given name, with the docker binary found in the given environment. This stops a
+// container started by a docker run command that had to be killed, as killing the docker CLI leaves the container
+// running.
+func killContainer(name string, env []string) error {
+	cmd := exec.Command(lookPath("docker", env), "kill", name)
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker kill %s failed: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// InvalidContainer is an error that occurs when the Container of a Command does not set exactly one of Image and ID.
+type InvalidContainer struct {
+	Image string
+	ID    string
+}
+
+func (err InvalidContainer) Error() string {
+	return fmt.Sprintf("Exactly one of Image and ID must be set on a Container, but got Image = '%s' and ID = '%s'", err.Image, err.ID)
+}
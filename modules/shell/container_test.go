@@ -0,0 +1,177 @@
+package shell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommandInNewContainer(t *testing.T) {
+	t.Parallel()
+
+	stubs := NewStubs(t)
+	stubs.Add(t, "docker", StubResponse{Stdout: "Terraform v0.11.7\n"})
+
+	workingDir := stubs.Dir
+	cmd := Command{
+		Command:    "terraform",
+		Args:       []string{"version"},
+		WorkingDir: workingDir,
+		Env:        map[string]string{"PATH": stubs.EnvVars()["PATH"], "TF_VAR_password": "hunter2"},
+		Container: &Container{
+			Image:      "hashicorp/terraform:0.11.7",
+			Mounts:     map[string]string{"/tmp": "/host-tmp"},
+			DockerArgs: []string{"--network", "host"},
+		},
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, "Terraform v0.11.7", out)
+
+	invocations := stubs.Invocations(t, "docker")
+	assert.Len(t, invocations, 1)
+
+	// The container gets a unique name, so it can be killed if the docker command has to be
+	args := invocations[0].Args
+	assert.Equal(t, []string{"run", "--rm", "--init", "--name"}, args[:4])
+	assert.Regexp(t, "^terratest-[0-9a-z]+$", args[4])
+	assert.Equal(t, []string{
+		"--entrypoint", "terraform",
+		"-v", workingDir + ":" + workingDir,
+		"-v", "/tmp:/host-tmp",
+		"-w", workingDir,
+		"-e", "PATH",
+		"-e", "TF_VAR_password",
+		"--network", "host",
+		"hashicorp/terraform:0.11.7",
+		"version",
+	}, args[5:])
+	assert.Equal(t, "hunter2", invocations[0].Env["TF_VAR_password"])
+}
+
+func TestDockerCommandMountsFoldersOfFileArgs(t *testing.T) {
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "terratest-docker-args")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	workingDir := filepath.Join(root, "test")
+	templateDir := filepath.Join(root, "examples", "packer")
+	varsDir := filepath.Join(root, "vars")
+	for _, dir := range []string{workingDir, templateDir, filepath.Join(templateDir, "scripts"), varsDir} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	for _, file := range []string{filepath.Join(workingDir, "main.tf"), filepath.Join(templateDir, "build.json"), filepath.Join(varsDir, "test.tfvars")} {
+		assert.NoError(t, ioutil.WriteFile(file, []byte{}, 0644))
+	}
+
+	cmd, _, err := dockerCommand(Command{
+		Command:    "packer",
+		Args:       []string{"build", "-var-file=" + filepath.Join(varsDir, "test.tfvars"), "main.tf", "../examples/packer/scripts", "../examples/packer/build.json", "/"},
+		WorkingDir: workingDir,
+		Container:  &Container{Image: "hashicorp/packer"},
+	})
+	assert.NoError(t, err)
+
+	mounts := []string{}
+	for i, arg := range cmd.Args {
+		if arg == "-v" {
+			mounts = append(mounts, cmd.Args[i+1])
+		}
+	}
+	assert.Equal(t, []string{workingDir + ":" + workingDir, templateDir + ":" + templateDir, varsDir + ":" + varsDir}, mounts)
+}
+
+func TestRunCommandInNewContainerKillsContainerOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	// A fake docker that ignores SIGTERM, like a docker run whose container doesn't stop, and records docker kill
+	binDir, err := ioutil.TempDir("", "terratest-fake-docker")
+	assert.NoError(t, err)
+	defer os.RemoveAll(binDir)
+
+	killed := filepath.Join(binDir, "killed")
+	script := "#!/bin/sh\nif [ \"$1\" = kill ]; then echo \"$2\" > " + killed + "; exit 0; fi\ntrap '' TERM\nsleep 30\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755))
+
+	_, err = RunCommandAndGetOutputE(t, Command{
+		Command:         "terraform",
+		Env:             map[string]string{"PATH": binDir + string(os.PathListSeparator) + os.Getenv("PATH")},
+		Timeout:         200 * time.Millisecond,
+		KillGracePeriod: 200 * time.Millisecond,
+		Container:       &Container{Image: "hashicorp/terraform"},
+	})
+	assert.IsType(t, TimeoutExceeded{}, err)
+
+	name, err := ioutil.ReadFile(killed)
+	assert.NoError(t, err)
+	assert.Regexp(t, "^terratest-[0-9a-z]+\n$", string(name))
+}
+
+func TestStartCommandInNewContainerKillReturnsError(t *testing.T) {
+	t.Parallel()
+
+	// A fake docker that ignores SIGTERM and fails to docker kill
+	binDir, err := ioutil.TempDir("", "terratest-fake-docker")
+	assert.NoError(t, err)
+	defer os.RemoveAll(binDir)
+
+	script := "#!/bin/sh\nif [ \"$1\" = kill ]; then echo \"No such container: $2\"; exit 1; fi\ntrap '' TERM\necho ready\nsleep 30\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755))
+
+	process := StartCommand(t, Command{
+		Command:         "terraform",
+		Env:             map[string]string{"PATH": binDir + string(os.PathListSeparator) + os.Getenv("PATH")},
+		KillGracePeriod: 200 * time.Millisecond,
+		Container:       &Container{Image: "hashicorp/terraform"},
+	})
+
+	// Wait for the fake docker to ignore SIGTERM before stopping it
+	_, err = process.WaitForOutput(regexp.MustCompile("ready"), 30*time.Second)
+	assert.NoError(t, err)
+
+	err = process.Kill()
+	if assert.IsType(t, StopFailed{}, err) {
+		assert.Contains(t, err.Error(), "No such container")
+	}
+	assert.True(t, process.Exited())
+
+	// Kill only stops the command once, and returns the same error every time
+	assert.Equal(t, err, process.Kill())
+}
+
+func TestRunCommandInExistingContainer(t *testing.T) {
+	t.Parallel()
+
+	stubs := NewStubs(t)
+	stubs.Add(t, "docker")
+
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	cmd := stubs.Apply(Command{
+		Command:     "cat",
+		StdinString: "hello",
+		Container:   &Container{ID: "my-container"},
+	})
+
+	RunCommand(t, cmd)
+
+	invocations := stubs.Invocations(t, "docker")
+	assert.Len(t, invocations, 1)
+	assert.Equal(t, []string{"exec", "-w", cwd, "-i", "-e", "PATH", "my-container", "cat"}, invocations[0].Args)
+	assert.Equal(t, "hello", invocations[0].Stdin)
+}
+
+func TestRunCommandInInvalidContainer(t *testing.T) {
+	t.Parallel()
+
+	_, err := RunCommandAndGetOutputE(t, Command{Command: "echo", Container: &Container{}})
+	assert.Equal(t, InvalidContainer{}, err)
+}
@@ -0,0 +1,311 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// InteractiveSession is an expect-style session with a command that prompts for input, such as terraform apply without
+// -auto-approve. The command runs with a pseudo-terminal as its stdin, stdout, and stderr, so it behaves as it would
+// for a user typing at a terminal. Use Expect to wait for a prompt, Send to answer it, and ExpectExitCode to check how
+// the command exited. Everything the command outputs, and every line sent to it, is logged, so the transcript shows up
+// in the test logs. Interactive sessions are only supported on Linux.
+type InteractiveSession struct {
+	t             *testing.T
+	command       Command
+	cmd           *exec.Cmd
+	containerName string // If the command runs in a new Docker container, the name of that container
+	pty           *os.File
+	done          chan struct{} // Closed once the command has exited and err is set
+	err           error
+
+	output   string      // Everything the command has output so far
+	position int         // How far into output Expect has matched so far
+	closed   bool        // True once there is no more output to read
+	updated  broadcaster // Notified every time output or closed changes
+	lock     sync.Mutex
+	stopOnce sync.Once
+}
+
+// SpawnInteractive starts the given command with a pseudo-terminal and returns a session for interacting with it. The
+// pseudo-terminal takes the place of the Stdin, StdinString, OutputWriter, and OutputFile of the command, so those
+// are ignored. The command is stopped, if it's still running, when the test and all its subtests complete.
+func SpawnInteractive(t *testing.T, command Command) *InteractiveSession {
+	session, err := SpawnInteractiveE(t, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// SpawnInteractiveE starts the given command with a pseudo-terminal and returns a session for interacting with it. The
+// pseudo-terminal takes the place of the Stdin, StdinString, OutputWriter, and OutputFile of the command, so those
+// are ignored. The command is stopped, if it's still running, when the test and all its subtests complete.
+func SpawnInteractiveE(t *testing.T, command Command) (*InteractiveSession, error) {
+	logger.RegisterSecret(command.Secrets...)
+
+	toRun := command
+	var containerName string
+	if command.Container != nil {
+		var err error
+		if toRun, containerName, err = dockerCommand(command); err != nil {
+			return nil, err
+		}
+		// docker has to allocate a terminal in the container too, for the command to see one
+		toRun.Args = append([]string{toRun.Args[0], "-i", "-t"}, toRun.Args[1:]...)
+	}
+
+	logger.LogfToModule(t, command.Logger, command.LogModule, logger.LevelInfo, "Running interactive command %s with args %s", toRun.Command, toRun.Args)
+
+	master, slave, err := openPty()
+	if err != nil {
+		return nil, err
+	}
+	// The command has its own copy of the slave end once it starts, and we have to close ours, or reading the master
+	// end never returns an error once the command exits
+	defer slave.Close()
+
+	env := formatEnvVars(toRun)
+	cmd := exec.Command(lookPath(toRun.Command, env), toRun.Args...)
+	cmd.Dir = toRun.WorkingDir
+	cmd.Env = env
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	setControllingTerminal(cmd)
+
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	session := &InteractiveSession{
+		t:             t,
+		command:       command,
+		cmd:           cmd,
+		containerName: containerName,
+		pty:           master,
+		done:          make(chan struct{}),
+	}
+
+	go session.readOutput()
+
+	t.Cleanup(session.Close)
+
+	return session, nil
+}
+
+// Read everything the command outputs until it exits, and then wait for the command
+func (session *InteractiveSession) readOutput() {
+	defer close(session.done)
+
+	var partialLine string
+	buffer := make([]byte, 4096)
+	for {
+		n, err := session.pty.Read(buffer)
+		if n > 0 {
+			text := string(buffer[:n])
+			session.addOutput(text)
+
+			lines := strings.Split(partialLine+text, "\n")
+			partialLine = lines[len(lines)-1]
+			for _, line := range lines[:len(lines)-1] {
+				session.logLine(line)
+			}
+		}
+		// Once the command exits, reading the master end of the pseudo-terminal fails (with EIO on Linux), so any
+		// error means we're done
+		if err != nil {
+			break
+		}
+	}
+	if partialLine != "" {
+		session.logLine(partialLine)
+	}
+
+	session.err = session.cmd.Wait()
+
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	session.closed = true
+	session.updated.notify()
+}
+
+func (session *InteractiveSession) logLine(line string) {
+	if !session.command.Quiet {
+		logger.LogToModule(session.t, session.command.Logger, session.command.LogModule, logger.LevelInfo, strings.TrimSuffix(line, "\r"))
+	}
+}
+
+func (session *InteractiveSession) addOutput(text string) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	session.output += text
+	session.updated.notify()
+}
+
+// Expect waits up to the given timeout for the command to output text that matches the given regex, and returns the
+// matching text. Each call only looks at output after the text matched by the previous call, as expect does. If
+// nothing matches in time, or the command exits first, fail the test.
+func (session *InteractiveSession) Expect(regex *regexp.Regexp, timeout time.Duration) string {
+	match, err := session.ExpectE(regex, timeout)
+	if err != nil {
+		session.t.Fatal(err)
+	}
+	return match
+}
+
+// ExpectE waits up to the given timeout for the command to output text that matches the given regex, and returns the
+// matching text. Each call only looks at output after the text matched by the previous call, as expect does. If
+// nothing matches in time, or the command exits first, return an ExpectFailed error.
+func (session *InteractiveSession) ExpectE(regex *regexp.Regexp, timeout time.Duration) (string, error) {
+	logger.LogfToModule(session.t, session.command.Logger, session.command.LogModule, logger.LevelInfo, "Expecting output matching %s within %s", regex, timeout)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		session.lock.Lock()
+		unmatched := session.output[session.position:]
+		closed := session.closed
+		updated := session.updated.wait()
+		if location := regex.FindStringIndex(unmatched); location != nil {
+			session.position += location[1]
+			session.lock.Unlock()
+			return unmatched[location[0]:location[1]], nil
+		}
+		session.lock.Unlock()
+
+		if closed {
+			return "", ExpectFailed{Pattern: regex.String(), Timeout: timeout, Exited: true, Output: unmatched}
+		}
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return "", ExpectFailed{Pattern: regex.String(), Timeout: timeout, Output: unmatched}
+		}
+	}
+}
+
+// Send writes the given line, followed by a newline, to the command, as if a user typed it and hit enter. If that
+// fails, fail the test.
+func (session *InteractiveSession) Send(line string) {
+	err := session.SendE(line)
+	if err != nil {
+		session.t.Fatal(err)
+	}
+}
+
+// SendE writes the given line, followed by a newline, to the command, as if a user typed it and hit enter.
+func (session *InteractiveSession) SendE(line string) error {
+	logger.LogfToModule(session.t, session.command.Logger, session.command.LogModule, logger.LevelInfo, "Sending input: %s", line)
+	_, err := session.pty.Write([]byte(line + "\n"))
+	return err
+}
+
+// ExpectExitCode waits up to the given timeout for the command to exit and checks that it exited with the given exit
+// code. If it doesn't, fail the test.
+func (session *InteractiveSession) ExpectExitCode(expectedExitCode int, timeout time.Duration) {
+	err := session.ExpectExitCodeE(expectedExitCode, timeout)
+	if err != nil {
+		session.t.Fatal(err)
+	}
+}
+
+// ExpectExitCodeE waits up to the given timeout for the command to exit and checks that it exited with the given exit
+// code. If it doesn't exit in time, return a CommandDidNotExit error, and if it exits with a different code, return an
+// UnexpectedExitCode error.
+func (session *InteractiveSession) ExpectExitCodeE(expectedExitCode int, timeout time.Duration) error {
+	logger.LogfToModule(session.t, session.command.Logger, session.command.LogModule, logger.LevelInfo, "Expecting command %s to exit with code %d within %s", session.command.Command, expectedExitCode, timeout)
+
+	select {
+	case <-session.done:
+	case <-time.After(timeout):
+		return CommandDidNotExit{Command: session.command.Command, Timeout: timeout}
+	}
+
+	exitCode, err := GetExitCodeForRunCommandError(session.err)
+	if err != nil {
+		return err
+	}
+	if exitCode != expectedExitCode {
+		return UnexpectedExitCode{Command: session.command.Command, Expected: expectedExitCode, Actual: exitCode}
+	}
+	return nil
+}
+
+// Close stops the command, if it's still running, in the same way Process.Kill does, and closes the pseudo-terminal.
+func (session *InteractiveSession) Close() {
+	session.stopOnce.Do(func() {
+		select {
+		case <-session.done:
+		default:
+			logger.LogfToModule(session.t, session.command.Logger, session.command.LogModule, logger.LevelInfo, "Stopping interactive command %s", session.command.Command)
+			terminateProcessGroup(session.cmd)
+
+			gracePeriod := session.command.KillGracePeriod
+			if gracePeriod == 0 {
+				gracePeriod = DefaultKillGracePeriod
+			}
+
+			select {
+			case <-session.done:
+			case <-time.After(gracePeriod):
+				killProcessGroup(session.cmd)
+				// Killing the docker CLI leaves the container it started running
+				if session.containerName != "" {
+					killContainer(session.containerName, session.cmd.Env)
+				}
+				<-session.done
+			}
+		}
+		session.pty.Close()
+	})
+}
+
+// ExpectFailed is an error that occurs when an interactive command doesn't output text matching a pattern, or doesn't
+// exit, in time.
+type ExpectFailed struct {
+	Pattern string
+	Timeout time.Duration
+	Exited  bool
+	Output  string // The output of the command since the last match
+}
+
+func (err ExpectFailed) Error() string {
+	if err.Exited {
+		return fmt.Sprintf("Command exited without outputting text matching %s. Output since the last match:\n%s", err.Pattern, err.Output)
+	}
+	return fmt.Sprintf("Timed out after %s waiting for %s. Output since the last match:\n%s", err.Timeout, err.Pattern, err.Output)
+}
+
+// CommandDidNotExit is an error that occurs when an interactive command doesn't exit in time.
+type CommandDidNotExit struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (err CommandDidNotExit) Error() string {
+	return fmt.Sprintf("Timed out after %s waiting for command %s to exit", err.Timeout, err.Command)
+}
+
+// UnexpectedExitCode is an error that occurs when a command exits with a different exit code than expected.
+type UnexpectedExitCode struct {
+	Command  string
+	Expected int
+	Actual   int
+}
+
+func (err UnexpectedExitCode) Error() string {
+	return fmt.Sprintf("Expected command %s to exit with code %d, but it exited with code %d", err.Command, err.Expected, err.Actual)
+}
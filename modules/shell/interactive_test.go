@@ -0,0 +1,50 @@
+//go:build linux
+// +build linux
+
+package shell
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteractiveSession(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "sh",
+		Args:    []string{"-c", `[ -t 0 ] && echo 'on a terminal'; printf 'Name? '; read name; echo "Hello, $name"; printf 'Continue? '; read answer; [ "$answer" = yes ] || exit 3`},
+	}
+
+	session := SpawnInteractive(t, cmd)
+	session.Expect(regexp.MustCompile("on a terminal"), 10*time.Second)
+	session.Expect(regexp.MustCompile(`Name\? `), 10*time.Second)
+	session.Send("Grunty")
+	assert.Equal(t, "Hello, Grunty", session.Expect(regexp.MustCompile(`Hello, \w+`), 10*time.Second))
+	session.Expect(regexp.MustCompile(`Continue\? `), 10*time.Second)
+
+	_, err := session.ExpectE(regexp.MustCompile("never printed"), 100*time.Millisecond)
+	assert.Equal(t, ExpectFailed{Pattern: "never printed", Timeout: 100 * time.Millisecond}, err)
+
+	session.Send("no")
+	assert.Equal(t, UnexpectedExitCode{Command: "sh", Expected: 0, Actual: 3}, session.ExpectExitCodeE(0, 10*time.Second))
+	session.ExpectExitCode(3, 10*time.Second)
+
+	_, err = session.ExpectE(regexp.MustCompile("Hello"), 10*time.Second)
+	assert.IsType(t, ExpectFailed{}, err)
+	assert.True(t, err.(ExpectFailed).Exited)
+}
+
+func TestInteractiveSessionClose(t *testing.T) {
+	t.Parallel()
+
+	session := SpawnInteractive(t, Command{Command: "sh", Args: []string{"-c", "read never; echo done"}})
+
+	start := time.Now()
+	session.Close()
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.IsType(t, UnexpectedExitCode{}, session.ExpectExitCodeE(0, time.Second))
+}
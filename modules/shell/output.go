@@ -0,0 +1,208 @@
+package shell
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// outputLine is a single line a command wrote to stdout or stderr, along with the time we read it.
+type outputLine struct {
+	text     string
+	isStderr bool
+	readAt   time.Time
+}
+
+// output contains the lines a command wrote to stdout and stderr, as well as the raw bytes.
+type output struct {
+	lines     []outputLine
+	rawStdout []byte
+	rawStderr []byte
+	closed    bool        // True once both stdout and stderr have been read to the end
+	updated   broadcaster // Notified every time this output changes
+	lock      sync.Mutex
+}
+
+func newOutput() *output {
+	return &output{}
+}
+
+// Add the given line to this output. The raw line is the line as the command wrote it, including its line ending.
+func (out *output) addLine(line outputLine, rawLine string) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	out.lines = append(out.lines, line)
+	if line.isStderr {
+		out.rawStderr = append(out.rawStderr, rawLine...)
+	} else {
+		out.rawStdout = append(out.rawStdout, rawLine...)
+	}
+	out.updated.notify()
+}
+
+// Mark this output as complete, as there is nothing more to read from stdout or stderr
+func (out *output) close() {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	out.closed = true
+	out.updated.notify()
+}
+
+// Return the lines added since the first given number of lines, whether this output is closed, and a channel that is
+// closed when this output next changes
+func (out *output) linesFrom(start int) ([]outputLine, bool, <-chan struct{}) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	lines := make([]outputLine, len(out.lines)-start)
+	copy(lines, out.lines[start:])
+	return lines, out.closed, out.updated.wait()
+}
+
+// Return the raw stdout or stderr from the given offset, whether this output is closed, and a channel that is closed
+// when this output next changes
+func (out *output) rawFrom(isStderr bool, offset int) ([]byte, bool, <-chan struct{}) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	raw := out.rawStdout
+	if isStderr {
+		raw = out.rawStderr
+	}
+	return raw[offset:], out.closed, out.updated.wait()
+}
+
+// Return the lines the command wrote to stdout
+func (out *output) stdout() string {
+	return out.join(func(line outputLine) bool { return !line.isStderr })
+}
+
+// Return the lines the command wrote to stderr
+func (out *output) stderr() string {
+	return out.join(func(line outputLine) bool { return line.isStderr })
+}
+
+// Return the lines the command wrote to stdout and stderr, in the order they were read
+func (out *output) combined() string {
+	return out.join(func(line outputLine) bool { return true })
+}
+
+// Join the lines that pass the given filter, ordered by the time they were read. The two streams are read in separate
+// goroutines, so the order in which lines were appended may differ slightly from the order in which they were read.
+func (out *output) join(filter func(line outputLine) bool) string {
+	out.lock.Lock()
+	lines := make([]outputLine, len(out.lines))
+	copy(lines, out.lines)
+	out.lock.Unlock()
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].readAt.Before(lines[j].readAt) })
+
+	texts := []string{}
+	for _, line := range lines {
+		if filter(line) {
+			texts = append(texts, line.text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// This function captures stdout and stderr into the given output while still printing it to the stdout and stderr of
+// this Go program (unless logOutput is false) using the given Logger, with the given module as the Module of the log
+// entries, as the goroutines that do the logging aren't on the stack of the code that ran the command. If tee is not
+// nil, the raw output is also written to it, with secrets masked, as in the logs. Each stream is read in its own
+// goroutine, so a command that fills up one stream while we're reading the other can't block, and every line is
+// timestamped as it's read, so the combined output has the lines in the order they were written. There is no limit on
+// the length of a line, so commands that output, for example, big JSON documents on a single line work too.
+func readStdoutAndStderr(t *testing.T, out *output, stdout io.Reader, stderr io.Reader, logOutput bool, log logger.Logger, logModule string, tee io.Writer) error {
+	defer out.close()
+
+	errs := make(chan error, 2)
+
+	readStream := func(stream io.Reader, isStderr bool) {
+		reader := bufio.NewReader(stream)
+		for {
+			rawLine, err := reader.ReadString('\n')
+			if len(rawLine) > 0 {
+				readAt := time.Now()
+				if tee != nil {
+					tee.Write([]byte(logger.Redact(rawLine)))
+				}
+
+				text := strings.TrimSuffix(strings.TrimSuffix(rawLine, "\n"), "\r")
+				if logOutput {
+					logger.LogToModule(t, log, logModule, logger.LevelInfo, text)
+				}
+				out.addLine(outputLine{text: text, isStderr: isStderr, readAt: readAt}, rawLine)
+			}
+
+			if err == io.EOF {
+				errs <- nil
+				return
+			} else if err != nil {
+				errs <- err
+				return
+			}
+		}
+	}
+
+	go readStream(stdout, false)
+	go readStream(stderr, true)
+
+	var firstErr error
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Open the writer to tee the raw output of the given command to, based on its OutputWriter and OutputFile. Return nil
+// if neither is set. The returned function closes the OutputFile, if any, and must always be called.
+func openOutputTee(command Command) (io.Writer, func(), error) {
+	writers := []io.Writer{}
+	closeTee := func() {}
+
+	if command.OutputWriter != nil {
+		writers = append(writers, command.OutputWriter)
+	}
+
+	if command.OutputFile != "" {
+		file, err := os.Create(command.OutputFile)
+		if err != nil {
+			return nil, closeTee, err
+		}
+		writers = append(writers, file)
+		closeTee = func() { file.Close() }
+	}
+
+	if len(writers) == 0 {
+		return nil, closeTee, nil
+	}
+
+	// Stdout and stderr are read in separate goroutines, so writes to the tee must be synchronized
+	return &syncWriter{writer: io.MultiWriter(writers...)}, closeTee, nil
+}
+
+// syncWriter is an io.Writer that can safely be written to from multiple goroutines.
+type syncWriter struct {
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+func (writer *syncWriter) Write(p []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	return writer.writer.Write(p)
+}
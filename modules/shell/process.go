@@ -0,0 +1,303 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// Process is a handle to a command running in the background, such as a dev server or docker-compose up, that was
+// started with StartCommand.
+type Process struct {
+	t             *testing.T
+	command       Command
+	cmd           *exec.Cmd
+	containerName string // If the command runs in a new Docker container, the name of that container
+	output        *output
+	done          chan struct{} // Closed once the command has exited and result and err are set
+	result        Result
+	err           error
+	stopOnce      sync.Once
+	stopErr       error // The error from signalling the command to stop, if any
+}
+
+// StartCommand starts the given command in the background and returns a handle to it. The command is stopped, if it's
+// still running, when the test and all its subtests complete, but you can also stop it earlier with Kill.
+func StartCommand(t *testing.T, command Command) *Process {
+	process, err := StartCommandE(t, command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return process
+}
+
+// StartCommandE starts the given command in the background and returns a handle to it. The command is stopped, if
+// it's still running, when the test and all its subtests complete, but you can also stop it earlier with Kill.
+func StartCommandE(t *testing.T, command Command) (*Process, error) {
+	process, err := startProcessE(t, command, true)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Cleanup(func() {
+		if err := process.Kill(); err != nil {
+			logger.LogfToModule(t, command.Logger, command.LogModule, logger.LevelWarn, "Failed to stop command %s during cleanup: %v", command.Command, err)
+		}
+	})
+
+	return process, nil
+}
+
+// Start the given command and return a handle to it. If newProcessGroup is true, the command runs in its own process
+// group, so it, and any processes it starts, can be stopped with a signal.
+func startProcessE(t *testing.T, command Command, newProcessGroup bool) (*Process, error) {
+	// Secrets are registered with the logger, so they are masked in everything it logs, including the line below and
+	// the output of the command
+	logger.RegisterSecret(command.Secrets...)
+
+	// The command we run differs from the given command if it has to run in a container, but everything else, such as
+	// the output capture and logging, stays the same
+	toRun := command
+	var containerName string
+	if command.Container != nil {
+		var err error
+		if toRun, containerName, err = dockerCommand(command); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.LogfToModule(t, command.Logger, command.LogModule, logger.LevelInfo, "Running command %s with args %s", toRun.Command, toRun.Args)
+
+	env := formatEnvVars(toRun)
+	cmd := exec.Command(lookPath(toRun.Command, env), toRun.Args...)
+	cmd.Dir = toRun.WorkingDir
+	cmd.Stdin = formatStdin(toRun)
+	cmd.Env = env
+
+	if newProcessGroup {
+		setProcessGroup(cmd)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	tee, closeTee, err := openOutputTee(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		closeTee()
+		return nil, err
+	}
+
+	process := &Process{
+		t:             t,
+		command:       command,
+		cmd:           cmd,
+		containerName: containerName,
+		output:        newOutput(),
+		done:          make(chan struct{}),
+	}
+
+	go func() {
+		defer close(process.done)
+		defer closeTee()
+
+		err := readStdoutAndStderr(t, process.output, stdout, stderr, !command.Quiet, command.Logger, command.LogModule, tee)
+		waitErr := cmd.Wait()
+		if err == nil {
+			err = waitErr
+		}
+		process.result, process.err = toResult(command, process.output, err)
+	}()
+
+	return process, nil
+}
+
+// Wait waits for the command to exit and returns its result. If the command exits with a non-zero exit code that is
+// not in its SuccessExitCodes, Wait returns an error along with the result.
+func (process *Process) Wait() (Result, error) {
+	<-process.done
+	return process.result, process.err
+}
+
+// Kill stops the command, if it's still running, by sending SIGTERM to its process group and, if it's still running
+// after its KillGracePeriod, SIGKILL. Kill returns once the command has exited, along with any error from signalling
+// the command or waiting for it to exit. Calling Kill again returns the same error.
+func (process *Process) Kill() error {
+	return process.stop("is being stopped")
+}
+
+// Exited returns true if the command has exited.
+func (process *Process) Exited() bool {
+	select {
+	case <-process.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stdout returns a reader for everything the command writes to stdout, starting from the beginning. Reads block until
+// the command writes more output or exits.
+func (process *Process) Stdout() io.Reader {
+	return &outputReader{output: process.output, isStderr: false}
+}
+
+// Stderr returns a reader for everything the command writes to stderr, starting from the beginning. Reads block until
+// the command writes more output or exits.
+func (process *Process) Stderr() io.Reader {
+	return &outputReader{output: process.output, isStderr: true}
+}
+
+// WaitForOutput waits up to the given timeout for the command to write a line to stdout or stderr that matches the
+// given regex, and returns that line. Lines written before this method was called count too. If no line matches
+// before the timeout, or before the command exits, return an OutputNotFound error.
+func (process *Process) WaitForOutput(regex *regexp.Regexp, timeout time.Duration) (string, error) {
+	logger.LogfToModule(process.t, process.command.Logger, process.command.LogModule, logger.LevelInfo, "Waiting up to %s for command %s to output a line matching %s", timeout, process.command.Command, regex)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	nextLine := 0
+	for {
+		lines, closed, updated := process.output.linesFrom(nextLine)
+		for _, line := range lines {
+			if regex.MatchString(line.text) {
+				return line.text, nil
+			}
+		}
+		nextLine += len(lines)
+
+		if closed {
+			return "", OutputNotFound{Command: process.command.Command, Pattern: regex.String(), Timeout: timeout, Exited: true}
+		}
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return "", OutputNotFound{Command: process.command.Command, Pattern: regex.String(), Timeout: timeout}
+		}
+	}
+}
+
+// Stop the command, if it's still running, by sending SIGTERM to its process group and, if it hasn't exited after the
+// kill grace period, SIGKILL (and docker kill, if it runs in a new container). Return once the command has exited,
+// along with any error from signalling the command or waiting for it to exit. A non-zero exit code, which stopping the
+// command is bound to cause, is not an error.
+func (process *Process) stop(reason string) error {
+	process.stopOnce.Do(func() {
+		process.stopErr = process.signalToStop(reason)
+	})
+
+	<-process.done
+	if process.stopErr != nil {
+		return process.stopErr
+	}
+	if _, isExitErr := process.err.(*exec.ExitError); process.err != nil && !isExitErr {
+		return process.err
+	}
+	return nil
+}
+
+// Signal the command to stop, if it's still running, as described in stop, and return any errors from doing so
+func (process *Process) signalToStop(reason string) error {
+	if process.Exited() {
+		return nil
+	}
+
+	gracePeriod := process.command.KillGracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = DefaultKillGracePeriod
+	}
+
+	errs := []string{}
+
+	logger.LogfToModule(process.t, process.command.Logger, process.command.LogModule, logger.LevelInfo, "Command %s %s. Sending SIGTERM to its process group.", process.command.Command, reason)
+	// The command may exit just before the signal is sent, which is fine
+	if err := terminateProcessGroup(process.cmd); err != nil && !process.Exited() {
+		errs = append(errs, fmt.Sprintf("failed to send SIGTERM: %v", err))
+	}
+
+	select {
+	case <-process.done:
+	case <-time.After(gracePeriod):
+		logger.LogfToModule(process.t, process.command.Logger, process.command.LogModule, logger.LevelInfo, "Command %s still running %s after SIGTERM. Sending SIGKILL to its process group.", process.command.Command, gracePeriod)
+		if err := killProcessGroup(process.cmd); err != nil && !process.Exited() {
+			errs = append(errs, fmt.Sprintf("failed to send SIGKILL: %v", err))
+		}
+		// Killing the docker CLI leaves the container it started running
+		if process.containerName != "" {
+			if err := killContainer(process.containerName, process.cmd.Env); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to kill its container: %v", err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return StopFailed{Command: process.command.Command, Errors: errs}
+	}
+	return nil
+}
+
+// outputReader is an io.Reader for the raw stdout or stderr of a command.
+type outputReader struct {
+	output   *output
+	isStderr bool
+	offset   int
+}
+
+func (reader *outputReader) Read(p []byte) (int, error) {
+	for {
+		data, closed, updated := reader.output.rawFrom(reader.isStderr, reader.offset)
+		if len(data) > 0 {
+			n := copy(p, data)
+			reader.offset += n
+			return n, nil
+		}
+		if closed {
+			return 0, io.EOF
+		}
+		<-updated
+	}
+}
+
+// OutputNotFound is an error that occurs when a command doesn't output a line matching a pattern in time.
+type OutputNotFound struct {
+	Command string
+	Pattern string
+	Timeout time.Duration
+	Exited  bool
+}
+
+func (err OutputNotFound) Error() string {
+	if err.Exited {
+		return fmt.Sprintf("command %s exited without outputting a line matching %s", err.Command, err.Pattern)
+	}
+	return fmt.Sprintf("command %s did not output a line matching %s within %s", err.Command, err.Pattern, err.Timeout)
+}
+
+// StopFailed is an error that occurs when stopping a command fails, e.g. because it couldn't be signalled.
+type StopFailed struct {
+	Command string
+	Errors  []string
+}
+
+func (err StopFailed) Error() string {
+	return fmt.Sprintf("Failed to stop command %s: %s", err.Command, strings.Join(err.Errors, "; "))
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Run the given command in its own process group, so we can signal it and every process it starts at once
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// Send SIGTERM to the process group of the given command
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// Send SIGKILL to the process group of the given command
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
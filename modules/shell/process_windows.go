@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package shell
+
+import (
+	"os/exec"
+)
+
+// Windows has no process groups we can signal, so the command runs as is
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// Windows has no SIGTERM, so the best we can do is kill the command
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// Kill the given command
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
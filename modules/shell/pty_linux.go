@@ -0,0 +1,53 @@
+//go:build linux
+// +build linux
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// Open a new pseudo-terminal and return its master and slave ends
+func openPty() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unlock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	var ptyNumber uint32
+	if err := ioctl(master, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptyNumber))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptyNumber), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	return master, slave, nil
+}
+
+func ioctl(file *os.File, request uintptr, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Run the given command in a new session, with the pseudo-terminal on its stdin as its controlling terminal. As the
+// session leader, the command also leads a new process group, so it can be stopped like any other command.
+func setControllingTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package shell
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Opening a pseudo-terminal is only implemented on Linux so far
+func openPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("interactive sessions are only supported on Linux")
+}
+
+func setControllingTerminal(cmd *exec.Cmd) {
+}
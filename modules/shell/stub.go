@@ -0,0 +1,259 @@
+package shell
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// Stubs is a temp folder of stub executables that stand in for real binaries, such as terraform or packer, so code
+// that runs those binaries can be tested offline. Every stub writes out scripted responses and records how it was
+// invoked. To use the stubs, put Dir at the front of the PATH of the command under test using EnvVars or Apply. Note
+// that the stubs are sh scripts, so they don't work on Windows.
+type Stubs struct {
+	Dir string // The temp folder that contains the stub executables
+}
+
+// StubResponse is what a stub executable does when it is invoked.
+type StubResponse struct {
+	Stdout   string // The text to write to stdout
+	Stderr   string // The text to write to stderr
+	ExitCode int    // The code to exit with
+}
+
+// StubInvocation is a record of a single invocation of a stub executable.
+type StubInvocation struct {
+	Args  []string          // The args the stub was invoked with
+	Env   map[string]string // The environment the stub was invoked with. Values that span several lines are not supported.
+	Stdin string            // Everything the stub read from stdin. Stdin is only read by commands the stubs were applied to with Apply.
+}
+
+// Every stub gets a folder with this name under Stubs.Dir to store its responses and invocations
+const stubDataFolder = ".stubs"
+
+// The stubs don't read stdin if this environment variable is set to true. Commands that don't get their stdin from Apply
+// inherit the stdin of the Go program, which may be a pipe that's never closed (e.g. on CI servers), so a stub that
+// read it would wait forever.
+const stubSkipStdinEnvVarName = "TERRATEST_STUB_SKIP_STDIN"
+
+// NewStubs creates an empty temp folder for stub executables. The folder is deleted when the test and all its subtests
+// complete.
+func NewStubs(t *testing.T) *Stubs {
+	stubs, err := NewStubsE(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stubs
+}
+
+// NewStubsE creates an empty temp folder for stub executables. The folder is deleted when the test and all its
+// subtests complete.
+func NewStubsE(t *testing.T) (*Stubs, error) {
+	dir, err := ioutil.TempDir("", "terratest-stubs")
+	if err != nil {
+		return nil, err
+	}
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	return &Stubs{Dir: dir}, nil
+}
+
+// Add creates a stub executable with the given name (e.g. terraform) that writes out the given responses. The first
+// invocation gets the first response, the second invocation the second response, and so on. Once the responses run
+// out, every further invocation gets the last response. With no responses, the stub writes nothing and exits with 0.
+func (stubs *Stubs) Add(t *testing.T, name string, responses ...StubResponse) {
+	err := stubs.AddE(t, name, responses...)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AddE creates a stub executable with the given name (e.g. terraform) that writes out the given responses. The first
+// invocation gets the first response, the second invocation the second response, and so on. Once the responses run
+// out, every further invocation gets the last response. With no responses, the stub writes nothing and exits with 0.
+func (stubs *Stubs) AddE(t *testing.T, name string, responses ...StubResponse) error {
+	logger.Logf(t, "Creating stub executable %s in %s", name, stubs.Dir)
+
+	if len(responses) == 0 {
+		responses = []StubResponse{{}}
+	}
+
+	dataDir := filepath.Join(stubs.Dir, stubDataFolder, name)
+	if err := os.RemoveAll(dataDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, "calls"), 0755); err != nil {
+		return err
+	}
+
+	for i, response := range responses {
+		responseDir := filepath.Join(dataDir, "responses", strconv.Itoa(i))
+		if err := os.MkdirAll(responseDir, 0755); err != nil {
+			return err
+		}
+
+		files := map[string]string{
+			"stdout":    response.Stdout,
+			"stderr":    response.Stderr,
+			"exit_code": strconv.Itoa(response.ExitCode),
+		}
+		for fileName, contents := range files {
+			if err := ioutil.WriteFile(filepath.Join(responseDir, fileName), []byte(contents), 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ioutil.WriteFile(filepath.Join(stubs.Dir, name), []byte(stubScript(dataDir, len(responses))), 0755)
+}
+
+// Return the sh script for a stub that stores its data in the given folder and has the given number of responses.
+// Every invocation claims the next free number by creating a folder for it, which is atomic, so invocations that
+// happen at the same time don't overwrite each other.
+func stubScript(dataDir string, numResponses int) string {
+	return fmt.Sprintf(`#!/bin/sh
+data=%s
+n=0
+while ! mkdir "$data/calls/$n" 2>/dev/null; do
+  n=$((n+1))
+done
+: > "$data/calls/$n/args"
+if [ $# -gt 0 ]; then
+  printf '%%s\0' "$@" > "$data/calls/$n/args"
+fi
+env > "$data/calls/$n/env"
+if [ "$%s" != "true" ] && [ ! -t 0 ]; then
+  cat > "$data/calls/$n/stdin"
+else
+  : > "$data/calls/$n/stdin"
+fi
+r=$n
+if [ "$r" -ge %d ]; then
+  r=%d
+fi
+cat "$data/responses/$r/stdout"
+cat "$data/responses/$r/stderr" 1>&2
+exit "$(cat "$data/responses/$r/exit_code")"
+`, quoteShellString(dataDir), stubSkipStdinEnvVarName, numResponses, numResponses-1)
+}
+
+// Quote the given string so sh treats it as a single literal word
+func quoteShellString(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// Invocations returns every invocation of the stub executable with the given name so far, in the order they happened.
+func (stubs *Stubs) Invocations(t *testing.T, name string) []StubInvocation {
+	invocations, err := stubs.InvocationsE(t, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return invocations
+}
+
+// InvocationsE returns every invocation of the stub executable with the given name so far, in the order they
+// happened.
+func (stubs *Stubs) InvocationsE(t *testing.T, name string) ([]StubInvocation, error) {
+	callsDir := filepath.Join(stubs.Dir, stubDataFolder, name, "calls")
+	entries, err := ioutil.ReadDir(callsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, StubNotFound(name)
+		}
+		return nil, err
+	}
+
+	callNumbers := []int{}
+	for _, entry := range entries {
+		if callNumber, err := strconv.Atoi(entry.Name()); err == nil {
+			callNumbers = append(callNumbers, callNumber)
+		}
+	}
+	sort.Ints(callNumbers)
+
+	invocations := []StubInvocation{}
+	for _, callNumber := range callNumbers {
+		invocation, err := readStubInvocation(filepath.Join(callsDir, strconv.Itoa(callNumber)))
+		if err != nil {
+			return nil, err
+		}
+		invocations = append(invocations, invocation)
+	}
+	return invocations, nil
+}
+
+// Read the invocation recorded in the given folder
+func readStubInvocation(callDir string) (StubInvocation, error) {
+	contents := map[string]string{}
+	for _, fileName := range []string{"args", "env", "stdin"} {
+		bytes, err := ioutil.ReadFile(filepath.Join(callDir, fileName))
+		if err != nil {
+			return StubInvocation{}, err
+		}
+		contents[fileName] = string(bytes)
+	}
+
+	args := []string{}
+	if contents["args"] != "" {
+		args = strings.Split(strings.TrimSuffix(contents["args"], "\x00"), "\x00")
+	}
+
+	env := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSuffix(contents["env"], "\n"), "\n") {
+		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	return StubInvocation{Args: args, Env: env, Stdin: contents["stdin"]}, nil
+}
+
+// EnvVars returns the environment variables that put the stub executables at the front of the PATH. Use these as the
+// Env of a Command, or as the environment variables of terraform.Options or packer.Options. The stubs don't read stdin
+// when they're used this way, as the command may inherit a stdin that's never closed, so use Apply to check what a
+// command writes to stdin.
+func (stubs *Stubs) EnvVars() map[string]string {
+	return map[string]string{
+		"PATH":                  stubs.path(),
+		stubSkipStdinEnvVarName: "true",
+	}
+}
+
+// Return the PATH with the stub executables at the front
+func (stubs *Stubs) path() string {
+	return stubs.Dir + string(os.PathListSeparator) + os.Getenv("PATH")
+}
+
+// Apply returns a copy of the given command with the stub executables at the front of its PATH. The stubs record what
+// the command writes to their stdin. If the command has neither Stdin nor StdinString set, its stdin is empty, rather
+// than the stdin of this Go program, so the stubs never wait for input that doesn't come.
+func (stubs *Stubs) Apply(command Command) Command {
+	env := map[string]string{}
+	for key, value := range command.Env {
+		env[key] = value
+	}
+	env["PATH"] = stubs.path()
+	command.Env = env
+
+	if command.Stdin == nil && command.StdinString == "" {
+		command.Stdin = strings.NewReader("")
+	}
+	return command
+}
+
+// StubNotFound is an error that occurs when looking up the invocations of a stub executable that was never added.
+type StubNotFound string
+
+func (name StubNotFound) Error() string {
+	return fmt.Sprintf("No stub executable called %s has been added", string(name))
+}
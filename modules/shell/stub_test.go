@@ -0,0 +1,81 @@
+package shell
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStubs(t *testing.T) {
+	t.Parallel()
+
+	stubs := NewStubs(t)
+	stubs.Add(t, "terratest-fake-tool",
+		StubResponse{Stdout: "first\n", Stderr: "warning\n", ExitCode: 3},
+		StubResponse{Stdout: "second\n"},
+	)
+
+	cmd := stubs.Apply(Command{
+		Command:     "terratest-fake-tool",
+		Args:        []string{"plan", "-var", "name=hello world"},
+		Env:         map[string]string{"FOO": "bar"},
+		StdinString: "some input",
+	})
+
+	result, err := RunCommandAndGetResultE(t, cmd)
+	assert.Error(t, err)
+	assert.Equal(t, Result{Stdout: "first", Stderr: "warning", Combined: result.Combined, ExitCode: 3}, result)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, "second", RunCommandAndGetOutput(t, cmd))
+	}
+
+	invocations := stubs.Invocations(t, "terratest-fake-tool")
+	assert.Len(t, invocations, 3)
+	assert.Equal(t, []string{"plan", "-var", "name=hello world"}, invocations[0].Args)
+	assert.Equal(t, "bar", invocations[0].Env["FOO"])
+	assert.Equal(t, "some input", invocations[0].Stdin)
+
+	_, err = stubs.InvocationsE(t, "not-added")
+	assert.Equal(t, StubNotFound("not-added"), err)
+}
+
+func TestStubsWithoutResponses(t *testing.T) {
+	t.Parallel()
+
+	stubs := NewStubs(t)
+	stubs.Add(t, "terratest-silent-tool")
+
+	out := RunCommandAndGetOutput(t, Command{Command: "terratest-silent-tool", Env: stubs.EnvVars()})
+	assert.Equal(t, "", out)
+
+	invocations := stubs.Invocations(t, "terratest-silent-tool")
+	assert.Len(t, invocations, 1)
+	assert.Equal(t, []string{}, invocations[0].Args)
+}
+
+func TestStubsDoNotWaitForStdinThatIsNeverClosed(t *testing.T) {
+	t.Parallel()
+
+	stubs := NewStubs(t)
+	stubs.Add(t, "terratest-stdin-tool", StubResponse{Stdout: "done"})
+
+	// A pipe that's never closed, like the stdin of a Go program on many CI servers
+	reader, writer, err := os.Pipe()
+	assert.NoError(t, err)
+	defer reader.Close()
+	defer writer.Close()
+
+	out := RunCommandAndGetOutput(t, Command{Command: "terratest-stdin-tool", Env: stubs.EnvVars(), Stdin: reader})
+	assert.Equal(t, "done", out)
+
+	cmd := stubs.Apply(Command{Command: "terratest-stdin-tool"})
+	assert.NotNil(t, cmd.Stdin)
+	assert.Equal(t, "done", RunCommandAndGetOutput(t, cmd))
+
+	invocations := stubs.Invocations(t, "terratest-stdin-tool")
+	assert.Len(t, invocations, 2)
+	assert.Equal(t, "", invocations[0].Stdin)
+	assert.Equal(t, "", invocations[1].Stdin)
+}
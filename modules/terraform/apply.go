@@ -43,5 +43,7 @@ func Apply(t *testing.T, options *Options) string {
 // ApplyE runs terraform apply with the given options and return stdout/stderr. Note that this method does NOT call destroy and
 // assumes the caller is responsible for cleaning up any resources created by running apply.
 func ApplyE(t *testing.T, options *Options) (string, error) {
+	// The apply may change the outputs, so look up which are sensitive again the next time an output is read
+	options.sensitiveOutputsRegistered = false
 	return RunTerraformCommandE(t, options, FormatArgs(options.Vars, "apply", "-input=false", "-lock=false", "-auto-approve")...)
 }
@@ -2,11 +2,9 @@ package terraform
 
 import (
 	"fmt"
-	"strings"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/collections"
-	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/shell"
 )
@@ -22,31 +20,40 @@ func RunTerraformCommand(t *testing.T, options *Options, args ...string) string
 
 // RunTerraformCommandE runs terraform with the given arguments and options and return stdout/stderr.
 func RunTerraformCommandE(t *testing.T, options *Options, args ...string) (string, error) {
+	return runTerraformCommandE(t, options, false, args...)
+}
+
+// Run terraform with the given arguments and options and return stdout/stderr. If quiet is true, the output of the
+// command is not logged.
+func runTerraformCommandE(t *testing.T, options *Options, quiet bool, args ...string) (string, error) {
+	RegisterSensitiveVars(t, options)
+
 	if options.NoColor && !collections.ListContains(args, "-no-color") {
 		args = append(args, "-no-color")
 	}
 
+	retryOptions := retry.Options{MaxRetries: options.MaxRetries, Backoff: retry.ConstantBackoff{Sleep: options.TimeBetweenRetries}, Logger: options.Logger}
 	description := fmt.Sprintf("Running terraform %v", args)
-	return retry.DoWithRetryE(t, description, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	return retry.DoWithRetryableErrorsAndOptionsE(t, description, options.RetryableTerraformErrors, retryOptions, func() (string, error) {
 		cmd := shell.Command{
-			Command:    "terraform",
+			Command:    terraformBinary(options),
 			Args:       args,
 			WorkingDir: options.TerraformDir,
 			Env:        options.EnvVars,
+			Quiet:      quiet,
+			Container:  options.Container,
+			Logger:     options.Logger,
+			LogModule:  "terraform",
 		}
 
-		out, err := shell.RunCommandAndGetOutputE(t, cmd)
-		if err == nil {
-			return out, nil
-		}
-
-		for errorText, errorMessage := range options.RetryableTerraformErrors {
-			if strings.Contains(out, errorText) {
-				logger.Logf(t, "terraform failed with the error '%s' but this error was expected and warrants a retry. Further details: %s\n", errorText, errorMessage)
-				return out, err
-			}
-		}
-
-		return out, retry.FatalError{Underlying: err}
+		return shell.RunCommandAndGetOutputE(t, cmd)
 	})
 }
+
+// Return the Terraform binary to run for the given options
+func terraformBinary(options *Options) string {
+	if options.TerraformBinary != "" {
+		return options.TerraformBinary
+	}
+	return "terraform"
+}
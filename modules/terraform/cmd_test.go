@@ -0,0 +1,49 @@
+package terraform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTerraformCommandRetriesOnRetryableErrors(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "terraform",
+		shell.StubResponse{Stderr: "Error: TLS handshake timeout", ExitCode: 1},
+		shell.StubResponse{Stdout: "Apply complete!"},
+	)
+
+	options := &Options{
+		EnvVars:                  stubs.EnvVars(),
+		RetryableTerraformErrors: map[string]string{"TLS handshake timeout": "Transient network error"},
+		MaxRetries:               3,
+		TimeBetweenRetries:       time.Millisecond,
+	}
+
+	out, err := RunTerraformCommandE(t, options, "apply", "-auto-approve")
+	assert.NoError(t, err)
+	assert.Equal(t, "Apply complete!", out)
+	assert.Len(t, stubs.Invocations(t, "terraform"), 2)
+}
+
+func TestRunTerraformCommandDoesNotRetryOtherErrors(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "terraform", shell.StubResponse{Stderr: "Error: invalid syntax", ExitCode: 1})
+
+	options := &Options{
+		EnvVars:                  stubs.EnvVars(),
+		RetryableTerraformErrors: map[string]string{"TLS handshake timeout": "Transient network error"},
+		MaxRetries:               3,
+		TimeBetweenRetries:       time.Millisecond,
+	}
+
+	_, err := RunTerraformCommandE(t, options, "apply", "-auto-approve")
+	assert.Error(t, err)
+	assert.Len(t, stubs.Invocations(t, "terraform"), 1)
+}
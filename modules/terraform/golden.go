@@ -0,0 +1,297 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// UpdateGoldenFilesEnvVarName is the name of the environment variable that, if set to true, makes the golden file
+// assertions in this file rewrite the golden files with the current values rather than comparing against them. E.g.:
+// TERRATEST_UPDATE_GOLDEN=true go test -run TestFoo. This is an environment variable, rather than a -update flag, as
+// a library package registering a flag would clash with the -update flag of any test package that imports it.
+const UpdateGoldenFilesEnvVarName = "TERRATEST_UPDATE_GOLDEN"
+
+// Return true if the golden files should be rewritten rather than compared against
+func updateGoldenFiles() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateGoldenFilesEnvVarName))
+	return update
+}
+
+const computedPlaceholder = "<computed>"
+
+// Regular expressions for values that change from one test run to the next, and what to replace them with
+var volatileValueReplacements = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	// AWS account IDs, including the ones in ARNs (e.g. arn:aws:iam::123456789012:role/foo)
+	{regexp.MustCompile(`\b\d{12}\b`), "<account>"},
+	// AWS resource IDs (e.g. i-0123456789abcdef0, sg-12345678)
+	{regexp.MustCompile(`\b(ami|eipalloc|eni|i|igw|lt|nat|acl|rtb|sg|snap|subnet|vol|vpc|vpce)-[0-9a-f]{8,17}\b`), "${1}-<id>"},
+	// RFC3339 timestamps (e.g. 2018-05-29T20:32:47Z)
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), "<timestamp>"},
+}
+
+// AssertPlanMatchesGolden runs terraform plan with the given options and checks that the planned resource changes
+// match the ones in the given golden JSON file, failing the test if they don't. See AssertPlanMatchesGoldenE for
+// details.
+func AssertPlanMatchesGolden(t *testing.T, options *Options, goldenFile string) {
+	err := AssertPlanMatchesGoldenE(t, options, goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertPlanMatchesGoldenE runs terraform plan with the given options and checks that the planned resource changes
+// match the ones in the given golden JSON file, returning an error if they don't. Values that aren't known until apply
+// time are replaced with <computed>, and volatile values, such as AWS account IDs, resource IDs, and timestamps, are
+// replaced with placeholders, so the golden file can be checked in. If the TERRATEST_UPDATE_GOLDEN environment variable
+// is set to true, the golden file is rewritten instead. Note that this requires Terraform 0.12 or newer, as it uses terraform show -json.
+func AssertPlanMatchesGoldenE(t *testing.T, options *Options, goldenFile string) error {
+	planJSON, err := showPlanJSONE(t, options)
+	if err != nil {
+		return err
+	}
+
+	changes, err := parsePlannedChanges(planJSON)
+	if err != nil {
+		return err
+	}
+
+	return assertMatchesGoldenE(t, changes, goldenFile)
+}
+
+// AssertOutputsMatchGolden runs terraform output with the given options and checks that the outputs match the ones in
+// the given golden JSON file, failing the test if they don't. See AssertOutputsMatchGoldenE for details.
+func AssertOutputsMatchGolden(t *testing.T, options *Options, goldenFile string) {
+	err := AssertOutputsMatchGoldenE(t, options, goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertOutputsMatchGoldenE runs terraform output with the given options and checks that the outputs match the ones in
+// the given golden JSON file, returning an error if they don't. Sensitive outputs are replaced with <sensitive> and
+// volatile values, such as AWS account IDs, resource IDs, and timestamps, are replaced with placeholders, so the
+// golden file can be checked in. If the TERRATEST_UPDATE_GOLDEN environment variable is set to true, the golden file
+// is rewritten instead.
+func AssertOutputsMatchGoldenE(t *testing.T, options *Options, goldenFile string) error {
+	// The JSON has the real values of the sensitive outputs, so don't log it
+	outputJSON, err := runTerraformCommandE(t, options, true, "output", "-json")
+	if err != nil {
+		return err
+	}
+
+	outputs, err := parseOutputValues(outputJSON)
+	if err != nil {
+		return err
+	}
+
+	return assertMatchesGoldenE(t, outputs, goldenFile)
+}
+
+// Run terraform plan with the given options, saving the plan to a temp file, and return the JSON representation of
+// that plan from terraform show -json
+func showPlanJSONE(t *testing.T, options *Options) (string, error) {
+	tmpDir, err := ioutil.TempDir("", "terratest-plan")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	planFile := filepath.Join(tmpDir, "plan.out")
+	if _, err := RunTerraformCommandE(t, options, FormatArgs(options.Vars, "plan", "-input=false", "-lock=false", fmt.Sprintf("-out=%s", planFile))...); err != nil {
+		return "", err
+	}
+
+	// The JSON has the real values of the sensitive attributes, so don't log it
+	return runTerraformCommandE(t, options, true, "show", "-json", planFile)
+}
+
+// plannedChange is the part of a resource change from terraform show -json that we compare against golden files
+type plannedChange struct {
+	Address string      `json:"address"`
+	Actions []string    `json:"actions"`
+	After   interface{} `json:"after"`
+}
+
+// The subset of the terraform show -json format we need
+type planJSONFormat struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions        []string    `json:"actions"`
+			After          interface{} `json:"after"`
+			AfterUnknown   interface{} `json:"after_unknown"`
+			AfterSensitive interface{} `json:"after_sensitive"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// Parse the given terraform show -json output into the list of normalized planned changes
+func parsePlannedChanges(planJSON string) ([]plannedChange, error) {
+	var plan planJSONFormat
+	if err := unmarshalTerraformJSON(planJSON, &plan); err != nil {
+		return nil, err
+	}
+
+	changes := []plannedChange{}
+	for _, resourceChange := range plan.ResourceChanges {
+		after := markValues(resourceChange.Change.After, resourceChange.Change.AfterUnknown, computedPlaceholder)
+		after = markValues(after, resourceChange.Change.AfterSensitive, logger.SecretMask)
+		changes = append(changes, plannedChange{
+			Address: resourceChange.Address,
+			Actions: resourceChange.Change.Actions,
+			After:   normalizeVolatileValues(after),
+		})
+	}
+	return changes, nil
+}
+
+// Parse the given terraform output -json output into a map of output name to normalized value
+func parseOutputValues(outputJSON string) (map[string]interface{}, error) {
+	outputs := map[string]outputMeta{}
+	if err := unmarshalTerraformJSON(outputJSON, &outputs); err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	for name, output := range outputs {
+		if output.Sensitive {
+			values[name] = logger.SecretMask
+		} else {
+			values[name] = normalizeVolatileValues(output.Value)
+		}
+	}
+	return values, nil
+}
+
+// The output we get from the shell package contains stderr as well as stdout, so skip anything Terraform may have
+// written before the JSON
+func unmarshalTerraformJSON(out string, value interface{}) error {
+	start := strings.Index(out, "{")
+	if start < 0 {
+		return InvalidOutputJSON(out)
+	}
+	return json.Unmarshal([]byte(out[start:]), value)
+}
+
+// Replace every value in after that the given marks mark with the given placeholder. The marks, such as after_unknown
+// or after_sensitive, have the same structure as after, with true for every value that's marked (e.g. that won't be
+// known until apply time, or that is sensitive).
+func markValues(after interface{}, marks interface{}, placeholder string) interface{} {
+	switch markValue := marks.(type) {
+	case bool:
+		if markValue {
+			return placeholder
+		}
+		return after
+	case map[string]interface{}:
+		afterMap, isMap := after.(map[string]interface{})
+		if !isMap && len(markValue) == 0 {
+			return after
+		}
+		result := map[string]interface{}{}
+		for key, value := range afterMap {
+			result[key] = value
+		}
+		for key, value := range markValue {
+			result[key] = markValues(afterMap[key], value, placeholder)
+		}
+		return result
+	case []interface{}:
+		afterSlice, _ := after.([]interface{})
+		length := len(afterSlice)
+		if len(markValue) > length {
+			length = len(markValue)
+		}
+		result := make([]interface{}, length)
+		for i := range result {
+			var afterItem, markItem interface{}
+			if i < len(afterSlice) {
+				afterItem = afterSlice[i]
+			}
+			if i < len(markValue) {
+				markItem = markValue[i]
+			}
+			result[i] = markValues(afterItem, markItem, placeholder)
+		}
+		return result
+	default:
+		return after
+	}
+}
+
+// Replace volatile values, such as AWS account IDs, resource IDs, and timestamps, anywhere in the given value with
+// placeholders, so the result is the same from one test run to the next
+func normalizeVolatileValues(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		for _, volatileValue := range volatileValueReplacements {
+			v = volatileValue.regex.ReplaceAllString(v, volatileValue.replacement)
+		}
+		return v
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range v {
+			result[key] = normalizeVolatileValues(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeVolatileValues(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// Compare the JSON representation of the given value against the contents of the given golden file, or rewrite the
+// golden file if the TERRATEST_UPDATE_GOLDEN environment variable is set
+func assertMatchesGoldenE(t *testing.T, value interface{}, goldenFile string) error {
+	actual, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	actual = append(actual, '\n')
+
+	if updateGoldenFiles() {
+		logger.Logf(t, "%s is set, so updating golden file %s", UpdateGoldenFilesEnvVarName, goldenFile)
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0777); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(goldenFile, actual, 0644)
+	}
+
+	expected, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		return err
+	}
+
+	if string(expected) != string(actual) {
+		return GoldenFileMismatch{Path: goldenFile, Expected: string(expected), Actual: string(actual)}
+	}
+	return nil
+}
+
+// GoldenFileMismatch is an error that occurs when a value does not match the contents of its golden file.
+type GoldenFileMismatch struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (err GoldenFileMismatch) Error() string {
+	return fmt.Sprintf("Value does not match golden file %s (run go test with %s=true to update it).\nExpected:\n%s\nActual:\n%s", err.Path, UpdateGoldenFilesEnvVarName, logger.Redact(err.Expected), logger.Redact(err.Actual))
+}
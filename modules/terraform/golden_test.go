@@ -0,0 +1,124 @@
+package terraform
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+const examplePlanJSON = `{
+  "format_version": "0.1",
+  "terraform_version": "0.12.0",
+  "resource_changes": [
+    {
+      "address": "aws_instance.example",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {
+          "ami": "ami-0123456789abcdef0",
+          "instance_type": "t2.micro",
+          "iam_instance_profile": "arn:aws:iam::123456789012:instance-profile/example",
+          "tags": {"Name": "example", "CreatedAt": "2018-05-29T20:32:47Z"},
+          "user_data": "export DB_PASSWORD=hunter2",
+          "root_block_device": [{"kms_key_id": "test-golden-kms-key", "volume_size": 8}]
+        },
+        "after_unknown": {
+          "arn": true,
+          "id": true,
+          "tags": {},
+          "security_groups": [true]
+        },
+        "after_sensitive": {
+          "user_data": true,
+          "root_block_device": [{"kms_key_id": true}]
+        }
+      }
+    }
+  ]
+}`
+
+func TestParsePlannedChanges(t *testing.T) {
+	t.Parallel()
+
+	changes, err := parsePlannedChanges(examplePlanJSON)
+	assert.NoError(t, err)
+
+	expected := []plannedChange{
+		{
+			Address: "aws_instance.example",
+			Actions: []string{"create"},
+			After: map[string]interface{}{
+				"ami":                  "ami-<id>",
+				"arn":                  "<computed>",
+				"iam_instance_profile": "arn:aws:iam::<account>:instance-profile/example",
+				"id":                   "<computed>",
+				"instance_type":        "t2.micro",
+				"root_block_device":    []interface{}{map[string]interface{}{"kms_key_id": "<sensitive>", "volume_size": 8.0}},
+				"security_groups":      []interface{}{"<computed>"},
+				"tags":                 map[string]interface{}{"Name": "example", "CreatedAt": "<timestamp>"},
+				"user_data":            "<sensitive>",
+			},
+		},
+	}
+	assert.Equal(t, expected, changes)
+}
+
+func TestParseOutputValues(t *testing.T) {
+	t.Parallel()
+
+	outputJSON := `{
+    "db_password": {"sensitive": true, "type": "string", "value": "hunter2"},
+    "instance_ids": {"sensitive": false, "type": "list", "value": ["i-0123456789abcdef0", "i-abcdef01"]}
+}`
+
+	values, err := parseOutputValues(outputJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"db_password":  "<sensitive>",
+		"instance_ids": []interface{}{"i-<id>", "i-<id>"},
+	}, values)
+}
+
+func TestAssertMatchesGolden(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", t.Name())
+	assert.NoError(t, err)
+	goldenFile := filepath.Join(tmpDir, "outputs.json")
+
+	golden := "{\n  \"foo\": \"bar\"\n}\n"
+	assert.NoError(t, ioutil.WriteFile(goldenFile, []byte(golden), 0644))
+
+	assert.NoError(t, assertMatchesGoldenE(t, map[string]interface{}{"foo": "bar"}, goldenFile))
+
+	err = assertMatchesGoldenE(t, map[string]interface{}{"foo": "baz"}, goldenFile)
+	assert.Equal(t, GoldenFileMismatch{Path: goldenFile, Expected: golden, Actual: "{\n  \"foo\": \"baz\"\n}\n"}, err)
+}
+
+func TestGoldenFileMismatchMasksSecrets(t *testing.T) {
+	t.Parallel()
+
+	logger.RegisterSecret("test-golden-mismatch-secret")
+	err := GoldenFileMismatch{Path: "outputs.json", Expected: "{}", Actual: `{"foo": "test-golden-mismatch-secret"}`}
+	assert.NotContains(t, err.Error(), "test-golden-mismatch-secret")
+	assert.Contains(t, err.Error(), `{"foo": "<sensitive>"}`)
+}
+
+// This test sets an environment variable, so it must not run in parallel with the other tests in this package
+func TestAssertMatchesGoldenUpdate(t *testing.T) {
+	t.Setenv(UpdateGoldenFilesEnvVarName, "true")
+
+	tmpDir, err := ioutil.TempDir("", t.Name())
+	assert.NoError(t, err)
+	goldenFile := filepath.Join(tmpDir, "golden", "outputs.json")
+
+	assert.NoError(t, assertMatchesGoldenE(t, map[string]interface{}{"foo": "baz"}, goldenFile))
+
+	contents, err := ioutil.ReadFile(goldenFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"foo\": \"baz\"\n}\n", string(contents))
+}
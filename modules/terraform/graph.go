@@ -0,0 +1,341 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/collections"
+)
+
+// DependencyGraph is the dependency graph of a Terraform configuration, as reported by the terraform graph command.
+// Each key in Dependencies is the name of a node (e.g. aws_security_group_rule.allow_all or
+// module.vpc.aws_subnet.private) and the value is the list of nodes it directly depends on.
+type DependencyGraph struct {
+	Nodes        []string
+	Dependencies map[string][]string
+}
+
+// Graph runs terraform graph with the given options and parses the resulting DOT output into a DependencyGraph. Note
+// that the Terraform code must have been initialized (e.g. with terraform init) first.
+func Graph(t *testing.T, options *Options) *DependencyGraph {
+	graph, err := GraphE(t, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graph
+}
+
+// GraphE runs terraform graph with the given options and parses the resulting DOT output into a DependencyGraph. Note
+// that the Terraform code must have been initialized (e.g. with terraform init) first.
+func GraphE(t *testing.T, options *Options) (*DependencyGraph, error) {
+	out, err := RunTerraformCommandE(t, options, "graph")
+	if err != nil {
+		return nil, err
+	}
+	return ParseGraph(out)
+}
+
+// Node names are DOT quoted IDs, which may contain escaped quotes (e.g. "[root] provider[\"registry.terraform.io/hashicorp/aws\"]"
+// in Terraform 0.13 and newer)
+var (
+	graphEdgeRegexp = regexp.MustCompile(`^\s*"((?:[^"\\]|\\.)+)"\s*->\s*"((?:[^"\\]|\\.)+)"`)
+	graphNodeRegexp = regexp.MustCompile(`^\s*"((?:[^"\\]|\\.)+)"\s*\[`)
+)
+
+// ParseGraph parses the DOT output of the terraform graph command into a DependencyGraph. Node names are normalized
+// by removing the "[root] " prefix and the " (expand)" suffix Terraform adds, so they look like the addresses
+// you use in Terraform code.
+func ParseGraph(dot string) (*DependencyGraph, error) {
+	if !strings.Contains(dot, "digraph") {
+		return nil, InvalidGraphOutput(dot)
+	}
+
+	graph := &DependencyGraph{Dependencies: map[string][]string{}}
+
+	for _, line := range strings.Split(dot, "\n") {
+		if matches := graphEdgeRegexp.FindStringSubmatch(line); len(matches) == 3 {
+			from := normalizeGraphNodeName(matches[1])
+			to := normalizeGraphNodeName(matches[2])
+			graph.addNode(from)
+			graph.addNode(to)
+			graph.Dependencies[from] = appendIfMissing(graph.Dependencies[from], to)
+		} else if matches := graphNodeRegexp.FindStringSubmatch(line); len(matches) == 2 {
+			graph.addNode(normalizeGraphNodeName(matches[1]))
+		}
+	}
+
+	sort.Strings(graph.Nodes)
+	for node := range graph.Dependencies {
+		sort.Strings(graph.Dependencies[node])
+	}
+
+	return graph, nil
+}
+
+// Terraform prefixes node names with the module path ("[root] ") and newer versions add an " (expand)" suffix to the
+// node that represents a resource or module. Other suffixes, such as " (close)", mark separate bookkeeping nodes, so
+// those are left alone.
+func normalizeGraphNodeName(name string) string {
+	name = unescapeGraphID(name)
+	name = strings.TrimPrefix(name, "[root] ")
+	return strings.TrimSuffix(name, " (expand)")
+}
+
+// Remove the escaping from the contents of a DOT quoted ID: \" for a quote and, as Terraform escapes those too, \\ for
+// a backslash
+func unescapeGraphID(id string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(id)
+}
+
+func (graph *DependencyGraph) addNode(node string) {
+	if _, exists := graph.Dependencies[node]; !exists {
+		graph.Dependencies[node] = []string{}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+}
+
+func appendIfMissing(list []string, item string) []string {
+	if collections.ListContains(list, item) {
+		return list
+	}
+	return append(list, item)
+}
+
+// HasNode returns true if the graph contains a node with the given name.
+func (graph *DependencyGraph) HasNode(node string) bool {
+	_, exists := graph.Dependencies[node]
+	return exists
+}
+
+// DependsOn returns true if node a depends on node b, either directly or through some other node.
+func (graph *DependencyGraph) DependsOn(a string, b string) bool {
+	visited := map[string]bool{}
+	toVisit := append([]string{}, graph.Dependencies[a]...)
+
+	for len(toVisit) > 0 {
+		node := toVisit[0]
+		toVisit = toVisit[1:]
+
+		if node == b {
+			return true
+		}
+		if visited[node] {
+			continue
+		}
+		visited[node] = true
+		toVisit = append(toVisit, graph.Dependencies[node]...)
+	}
+
+	return false
+}
+
+// Cycles returns every dependency cycle in the graph. Each cycle is the sorted list of nodes that make it up.
+func (graph *DependencyGraph) Cycles() [][]string {
+	// This is Tarjan's strongly connected components algorithm. Every strongly connected component with more than one
+	// node, or with a node that depends on itself, is a cycle.
+	index := 0
+	indexes := map[string]int{}
+	lowLinks := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	cycles := [][]string{}
+
+	var strongConnect func(node string)
+	strongConnect = func(node string) {
+		indexes[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, dependency := range graph.Dependencies[node] {
+			if _, visited := indexes[dependency]; !visited {
+				strongConnect(dependency)
+				lowLinks[node] = minInt(lowLinks[node], lowLinks[dependency])
+			} else if onStack[dependency] {
+				lowLinks[node] = minInt(lowLinks[node], indexes[dependency])
+			}
+		}
+
+		if lowLinks[node] != indexes[node] {
+			return
+		}
+
+		component := []string{}
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+
+		if len(component) > 1 || graph.dependsDirectlyOn(node, node) {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if _, visited := indexes[node]; !visited {
+			strongConnect(node)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+func (graph *DependencyGraph) dependsDirectlyOn(a string, b string) bool {
+	return collections.ListContains(graph.Dependencies[a], b)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Orphans returns the nodes that neither depend on, nor are depended on by, any other node in the graph. Terraform's
+// bookkeeping nodes (root, meta.* and providers) are ignored, as every resource is connected to them anyway. An
+// orphaned resource is often a sign of a missing reference that Terraform needs to order operations correctly.
+func (graph *DependencyGraph) Orphans() []string {
+	connected := map[string]bool{}
+
+	for _, node := range graph.Nodes {
+		if isGraphBookkeepingNode(node) {
+			continue
+		}
+		for _, dependency := range graph.Dependencies[node] {
+			if isGraphBookkeepingNode(dependency) || dependency == node {
+				continue
+			}
+			connected[node] = true
+			connected[dependency] = true
+		}
+	}
+
+	orphans := []string{}
+	for _, node := range graph.Nodes {
+		if !isGraphBookkeepingNode(node) && !connected[node] {
+			orphans = append(orphans, node)
+		}
+	}
+	return orphans
+}
+
+func isGraphBookkeepingNode(node string) bool {
+	return node == "root" || strings.HasPrefix(node, "meta.") || strings.HasPrefix(node, "provider.") || strings.HasPrefix(node, "provider[")
+}
+
+// AssertDependsOn checks that node a in the given graph depends on node b, directly or indirectly, and fails the test
+// if it does not.
+func AssertDependsOn(t *testing.T, graph *DependencyGraph, a string, b string) {
+	err := AssertDependsOnE(t, graph, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertDependsOnE checks that node a in the given graph depends on node b, directly or indirectly, and returns an
+// error if it does not.
+func AssertDependsOnE(t *testing.T, graph *DependencyGraph, a string, b string) error {
+	for _, node := range []string{a, b} {
+		if !graph.HasNode(node) {
+			return GraphNodeNotFound(node)
+		}
+	}
+	if !graph.DependsOn(a, b) {
+		return MissingDependency{From: a, To: b}
+	}
+	return nil
+}
+
+// AssertNoCycles checks that the given graph has no dependency cycles and fails the test if it does.
+func AssertNoCycles(t *testing.T, graph *DependencyGraph) {
+	err := AssertNoCyclesE(t, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertNoCyclesE checks that the given graph has no dependency cycles and returns an error if it does.
+func AssertNoCyclesE(t *testing.T, graph *DependencyGraph) error {
+	if cycles := graph.Cycles(); len(cycles) > 0 {
+		return DependencyCycles(cycles)
+	}
+	return nil
+}
+
+// AssertNoOrphans checks that the given graph has no orphaned nodes (see DependencyGraph.Orphans) other than the
+// given allowed ones and fails the test if it does.
+func AssertNoOrphans(t *testing.T, graph *DependencyGraph, allowed ...string) {
+	err := AssertNoOrphansE(t, graph, allowed...)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// AssertNoOrphansE checks that the given graph has no orphaned nodes (see DependencyGraph.Orphans) other than the
+// given allowed ones and returns an error if it does.
+func AssertNoOrphansE(t *testing.T, graph *DependencyGraph, allowed ...string) error {
+	unexpected := []string{}
+	for _, orphan := range graph.Orphans() {
+		if !collections.ListContains(allowed, orphan) {
+			unexpected = append(unexpected, orphan)
+		}
+	}
+	if len(unexpected) > 0 {
+		return OrphanedNodes(unexpected)
+	}
+	return nil
+}
+
+// InvalidGraphOutput is an error that occurs when the output of terraform graph can't be parsed.
+type InvalidGraphOutput string
+
+func (output InvalidGraphOutput) Error() string {
+	return fmt.Sprintf("Output of terraform graph does not look like a DOT digraph: %s", string(output))
+}
+
+// GraphNodeNotFound is an error that occurs when a node can't be found in a DependencyGraph.
+type GraphNodeNotFound string
+
+func (node GraphNodeNotFound) Error() string {
+	return fmt.Sprintf("Node %s not found in the Terraform graph", string(node))
+}
+
+// MissingDependency is an error that occurs when a node in a DependencyGraph does not depend on another node as
+// expected.
+type MissingDependency struct {
+	From string
+	To   string
+}
+
+func (err MissingDependency) Error() string {
+	return fmt.Sprintf("Expected %s to depend on %s, but it does not", err.From, err.To)
+}
+
+// DependencyCycles is an error that occurs when a DependencyGraph has cycles.
+type DependencyCycles [][]string
+
+func (cycles DependencyCycles) Error() string {
+	descriptions := []string{}
+	for _, cycle := range cycles {
+		descriptions = append(descriptions, fmt.Sprintf("[%s]", strings.Join(cycle, ", ")))
+	}
+	return fmt.Sprintf("Found %d dependency cycle(s) in the Terraform graph: %s", len(cycles), strings.Join(descriptions, ", "))
+}
+
+// OrphanedNodes is an error that occurs when a DependencyGraph has nodes that are not connected to any other node.
+type OrphanedNodes []string
+
+func (nodes OrphanedNodes) Error() string {
+	return fmt.Sprintf("Found nodes in the Terraform graph that nothing depends on and that depend on nothing: %s", strings.Join(nodes, ", "))
+}
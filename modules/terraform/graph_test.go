@@ -0,0 +1,128 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleGraphOutput = `digraph {
+	compound = "true"
+	newrank = "true"
+	subgraph "root" {
+		"[root] aws_instance.example" [label = "aws_instance.example", shape = "box"]
+		"[root] aws_security_group.example" [label = "aws_security_group.example", shape = "box"]
+		"[root] aws_security_group_rule.allow_all" [label = "aws_security_group_rule.allow_all", shape = "box"]
+		"[root] aws_s3_bucket.logs" [label = "aws_s3_bucket.logs", shape = "box"]
+		"[root] provider.aws" [label = "provider.aws", shape = "diamond"]
+		"[root] aws_instance.example" -> "[root] aws_security_group.example"
+		"[root] aws_security_group.example" -> "[root] provider.aws"
+		"[root] aws_security_group_rule.allow_all" -> "[root] aws_security_group.example"
+		"[root] aws_s3_bucket.logs (expand)" -> "[root] provider.aws"
+		"[root] meta.count-boundary (count boundary fixup)" -> "[root] aws_instance.example"
+		"[root] provider.aws (close)" -> "[root] aws_instance.example"
+		"[root] root" -> "[root] meta.count-boundary (count boundary fixup)"
+		"[root] root" -> "[root] provider.aws (close)"
+	}
+}
+`
+
+func TestParseGraph(t *testing.T) {
+	t.Parallel()
+
+	graph, err := ParseGraph(exampleGraphOutput)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"aws_instance.example",
+		"aws_s3_bucket.logs",
+		"aws_security_group.example",
+		"aws_security_group_rule.allow_all",
+		"meta.count-boundary (count boundary fixup)",
+		"provider.aws",
+		"provider.aws (close)",
+		"root",
+	}, graph.Nodes)
+	assert.Equal(t, []string{"aws_security_group.example"}, graph.Dependencies["aws_instance.example"])
+	assert.Equal(t, []string{"meta.count-boundary (count boundary fixup)", "provider.aws (close)"}, graph.Dependencies["root"])
+
+	assert.True(t, graph.DependsOn("aws_instance.example", "provider.aws"))
+	assert.False(t, graph.DependsOn("aws_security_group.example", "aws_security_group_rule.allow_all"))
+
+	assert.NoError(t, AssertDependsOnE(t, graph, "aws_security_group_rule.allow_all", "aws_security_group.example"))
+	assert.Equal(t, MissingDependency{From: "aws_instance.example", To: "aws_security_group_rule.allow_all"}, AssertDependsOnE(t, graph, "aws_instance.example", "aws_security_group_rule.allow_all"))
+	assert.Equal(t, GraphNodeNotFound("aws_instance.missing"), AssertDependsOnE(t, graph, "aws_instance.missing", "provider.aws"))
+}
+
+// The output of terraform graph in Terraform 0.13 and newer, where provider nodes contain escaped quotes
+const exampleGraphOutputTerraform013 = `digraph {
+	compound = "true"
+	newrank = "true"
+	subgraph "root" {
+		"[root] aws_instance.example (expand)" [label = "aws_instance.example", shape = "box"]
+		"[root] provider[\"registry.terraform.io/hashicorp/aws\"]" [label = "provider[\"registry.terraform.io/hashicorp/aws\"]", shape = "diamond"]
+		"[root] aws_instance.example (expand)" -> "[root] provider[\"registry.terraform.io/hashicorp/aws\"]"
+		"[root] meta.count-boundary (EachMode fixup)" -> "[root] aws_instance.example (expand)"
+		"[root] provider[\"registry.terraform.io/hashicorp/aws\"] (close)" -> "[root] aws_instance.example (expand)"
+		"[root] root" -> "[root] meta.count-boundary (EachMode fixup)"
+		"[root] root" -> "[root] provider[\"registry.terraform.io/hashicorp/aws\"] (close)"
+	}
+}
+`
+
+func TestParseGraphWithEscapedQuotes(t *testing.T) {
+	t.Parallel()
+
+	graph, err := ParseGraph(exampleGraphOutputTerraform013)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"aws_instance.example",
+		"meta.count-boundary (EachMode fixup)",
+		`provider["registry.terraform.io/hashicorp/aws"]`,
+		`provider["registry.terraform.io/hashicorp/aws"] (close)`,
+		"root",
+	}, graph.Nodes)
+	assert.NoError(t, AssertDependsOnE(t, graph, "aws_instance.example", `provider["registry.terraform.io/hashicorp/aws"]`))
+}
+
+func TestParseGraphInvalidOutput(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseGraph("Error: not a graph")
+	assert.Equal(t, InvalidGraphOutput("Error: not a graph"), err)
+}
+
+func TestDependencyGraphCycles(t *testing.T) {
+	t.Parallel()
+
+	graph := &DependencyGraph{
+		Nodes: []string{"a", "b", "c", "d", "e"},
+		Dependencies: map[string][]string{
+			"a": {"b"},
+			"b": {"c"},
+			"c": {"a"},
+			"d": {"d"},
+			"e": {"a"},
+		},
+	}
+
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d"}}, graph.Cycles())
+	assert.Error(t, AssertNoCyclesE(t, graph))
+
+	acyclic, err := ParseGraph(exampleGraphOutput)
+	assert.NoError(t, err)
+	assert.Empty(t, acyclic.Cycles())
+	assert.NoError(t, AssertNoCyclesE(t, acyclic))
+}
+
+func TestDependencyGraphOrphans(t *testing.T) {
+	t.Parallel()
+
+	graph, err := ParseGraph(exampleGraphOutput)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"aws_s3_bucket.logs"}, graph.Orphans())
+	assert.Equal(t, OrphanedNodes{"aws_s3_bucket.logs"}, AssertNoOrphansE(t, graph))
+	assert.NoError(t, AssertNoOrphansE(t, graph, "aws_s3_bucket.logs"))
+}
@@ -1,16 +1,27 @@
 package terraform
 
-import "time"
+import (
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
 
 // Options for running Terraform commands
 type Options struct {
+	TerraformBinary          string                 // The name of, or path to, the Terraform binary to run. Defaults to terraform.
 	TerraformDir             string                 // The path to the folder where the Terraform code is defined.
 	Vars                     map[string]interface{} // The vars to pass to Terraform commands using the -var option.
+	SensitiveVars            []string               // The names of the Vars whose values are secrets. Those values are masked in all log output, if they are strings (or lists or maps of strings), however short (see logger.RegisterSecret).
 	EnvVars                  map[string]string      // Environment variables to set when running Terraform
 	BackendConfig            map[string]interface{} // The vars to pass to the terraform init command for extra configuration for the backend
-	RetryableTerraformErrors map[string]string      // If Terraform apply fails with one of these (transient) errors, retry. The keys are text to look for in the error and the message is what to display to a user if that error is found.
+	RetryableTerraformErrors map[string]string      // If Terraform fails with one of these (transient) errors, retry. The keys are regular expressions (escape plain text with regexp.QuoteMeta) to look for in the output and error and the message is what to display to a user if that error is found.
 	MaxRetries               int                    // Maximum number of times to retry errors matching RetryableTerraformErrors
 	TimeBetweenRetries       time.Duration          // The amount of time to wait between retries
 	Upgrade                  bool                   // Whether the -upgrade flag of the terraform init command should be set to true or not
 	NoColor                  bool                   // Whether the -no-color flag will be set for any Terraform command or not
+	Container                *shell.Container       // If set, Terraform runs in this Docker container (e.g. one started from the hashicorp/terraform image) rather than on this machine
+	Logger                   logger.Logger          `json:"-"` // The Logger to log Terraform commands and their output with. Defaults to the default Logger of the logger package.
+
+	sensitiveOutputsRegistered bool // True once the sensitive outputs have been registered as secrets. Every apply resets it, as it may change the outputs.
 }
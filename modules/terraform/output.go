@@ -15,8 +15,12 @@ func Output(t *testing.T, options *Options, key string) string {
 	return out
 }
 
-// OutputE calls terraform output for the given variable and return its value.
+// OutputE calls terraform output for the given variable and return its value. The values of any outputs marked as
+// sensitive are masked in the log output, but returned as-is. To find the sensitive outputs, the first call after
+// every apply also runs terraform output -json.
 func OutputE(t *testing.T, options *Options, key string) (string, error) {
+	registerSensitiveOutputs(t, options)
+
 	output, err := RunTerraformCommandE(t, options, "output", "-no-color", key)
 
 	if err != nil {
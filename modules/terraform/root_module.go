@@ -0,0 +1,271 @@
+package terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// RootModule is a Terraform root module that can be generated from Go code. Most test fixtures are throwaway root
+// modules that do nothing more than wrap the module under test with some inputs and a provider, so rather than
+// checking in a separate fixture folder for each combination of inputs you want to test, you can build one of these,
+// write it to a temp folder using WriteRootModuleToTemp, and point Options.TerraformDir at the result.
+type RootModule struct {
+	Providers []Provider // The provider blocks to include in the root module
+	Modules   []Module   // The module blocks to include in the root module
+}
+
+// Provider is a provider block in a generated root module.
+type Provider struct {
+	Name   string                 // The name of the provider (e.g. aws)
+	Config map[string]interface{} // The arguments to set in the provider block (e.g. region, alias)
+}
+
+// Module is a module block in a generated root module.
+type Module struct {
+	Name         string                 // The name of the module block (e.g. the "vpc" in module.vpc)
+	Source       string                 // The module source. Relative local paths are converted to absolute paths, as the root module is written to a temp folder.
+	Version      string                 // The version constraint to use for a registry module source. Optional.
+	Inputs       map[string]interface{} // The input variables to pass to the module
+	Outputs      []string               // The module outputs to re-export from the root module. If nil and Source is a local folder, every output declared in that folder is re-exported.
+	OutputPrefix string                 // If set, every re-exported output is named <OutputPrefix><output name>. Useful when several modules declare the same output.
+}
+
+// HclExpression is a raw Terraform expression, such as module.vpc.vpc_id or var.name, that should be written into a
+// generated root module as an interpolation rather than as a quoted string.
+type HclExpression string
+
+// NewRootModule creates a new, empty RootModule.
+func NewRootModule() *RootModule {
+	return &RootModule{}
+}
+
+// AddProvider adds a provider block with the given name and config to this root module and returns the root module so
+// calls can be chained.
+func (rootModule *RootModule) AddProvider(name string, config map[string]interface{}) *RootModule {
+	rootModule.Providers = append(rootModule.Providers, Provider{Name: name, Config: config})
+	return rootModule
+}
+
+// AddModule adds a module block with the given name, source, and inputs to this root module and returns the root
+// module so calls can be chained.
+func (rootModule *RootModule) AddModule(name string, source string, inputs map[string]interface{}) *RootModule {
+	rootModule.Modules = append(rootModule.Modules, Module{Name: name, Source: source, Inputs: inputs})
+	return rootModule
+}
+
+// WriteRootModuleToTemp renders the given root module and writes it to a main.tf file in a temp folder with a unique
+// name and the given prefix. This method returns the path to that temp folder, which can be used as the TerraformDir
+// in Options.
+func WriteRootModuleToTemp(t *testing.T, rootModule *RootModule, tempFolderPrefix string) string {
+	out, err := WriteRootModuleToTempE(t, rootModule, tempFolderPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// WriteRootModuleToTempE renders the given root module and writes it to a main.tf file in a temp folder with a unique
+// name and the given prefix. This method returns the path to that temp folder, which can be used as the TerraformDir
+// in Options.
+func WriteRootModuleToTempE(t *testing.T, rootModule *RootModule, tempFolderPrefix string) (string, error) {
+	hcl, err := rootModule.Render()
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := ioutil.TempDir("", tempFolderPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	mainFile := filepath.Join(tmpDir, "main.tf")
+	logger.Logf(t, "Writing generated Terraform root module to %s", mainFile)
+
+	if err := ioutil.WriteFile(mainFile, []byte(hcl), 0644); err != nil {
+		return "", err
+	}
+
+	return tmpDir, nil
+}
+
+// Render returns the HCL for this root module. Provider blocks come first, then module blocks, then an output block
+// for every re-exported module output.
+func (rootModule *RootModule) Render() (string, error) {
+	var builder strings.Builder
+	outputSources := map[string]string{}
+
+	for _, provider := range rootModule.Providers {
+		fmt.Fprintf(&builder, "provider %s {\n", strconv.Quote(provider.Name))
+		writeHclAttributes(&builder, provider.Config, 1)
+		builder.WriteString("}\n\n")
+	}
+
+	for _, module := range rootModule.Modules {
+		source, err := resolveModuleSource(module.Source)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&builder, "module %s {\n", strconv.Quote(module.Name))
+		fmt.Fprintf(&builder, "  source = %s\n", strconv.Quote(source))
+		if module.Version != "" {
+			fmt.Fprintf(&builder, "  version = %s\n", strconv.Quote(module.Version))
+		}
+		writeHclAttributes(&builder, module.Inputs, 1)
+		builder.WriteString("}\n\n")
+
+		outputs := module.Outputs
+		if outputs == nil && isLocalModuleSource(module.Source) {
+			outputs, err = findOutputsInFolder(source)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		for _, output := range outputs {
+			outputName := module.OutputPrefix + output
+			if otherModule, alreadyExported := outputSources[outputName]; alreadyExported {
+				return "", DuplicateRootModuleOutput{OutputName: outputName, FirstModule: otherModule, SecondModule: module.Name}
+			}
+			outputSources[outputName] = module.Name
+
+			fmt.Fprintf(&builder, "output %s {\n", strconv.Quote(outputName))
+			fmt.Fprintf(&builder, "  value = %s\n", toHclLiteral(HclExpression(fmt.Sprintf("module.%s.%s", module.Name, output)), 1))
+			builder.WriteString("}\n\n")
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n", nil
+}
+
+// Local module sources in Terraform must start with ./ or ../ (or be an absolute path)
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || filepath.IsAbs(source)
+}
+
+// The generated root module lives in a temp folder, so relative local module sources have to be converted to absolute
+// paths for Terraform to find them.
+func resolveModuleSource(source string) (string, error) {
+	if !isLocalModuleSource(source) || filepath.IsAbs(source) {
+		return source, nil
+	}
+	return filepath.Abs(source)
+}
+
+var outputBlockRegexp = regexp.MustCompile(`(?m)^\s*output\s+"([^"]+)"`)
+
+// Find the names of all the outputs declared in the .tf files in the given folder
+func findOutputsInFolder(folder string) ([]string, error) {
+	tfFiles, err := filepath.Glob(filepath.Join(folder, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := []string{}
+	for _, tfFile := range tfFiles {
+		contents, err := ioutil.ReadFile(tfFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range outputBlockRegexp.FindAllStringSubmatch(string(contents), -1) {
+			outputs = append(outputs, match[1])
+		}
+	}
+
+	sort.Strings(outputs)
+	return outputs, nil
+}
+
+// Write the given attributes, sorted by key so the rendered output is deterministic, at the given indentation level
+func writeHclAttributes(builder *strings.Builder, attributes map[string]interface{}, indent int) {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(builder, "%s%s = %s\n", strings.Repeat("  ", indent), key, toHclLiteral(attributes[key], indent))
+	}
+}
+
+// Convert the given Go value to an HCL literal that can be written into a .tf file. Unlike toHclString, which has to
+// work around Terraform bugs in -var parsing, this writes numbers and booleans natively. Maps are written with their
+// keys sorted so the output is deterministic.
+func toHclLiteral(value interface{}, indent int) string {
+	if value == nil {
+		return "null"
+	}
+
+	switch v := value.(type) {
+	case HclExpression:
+		return strconv.Quote(fmt.Sprintf("${%s}", string(v)))
+	case string:
+		return quoteHclString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	if slice, isSlice := tryToConvertToGenericSlice(value); isSlice {
+		hclValues := []string{}
+		for _, item := range slice {
+			hclValues = append(hclValues, toHclLiteral(item, indent))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(hclValues, ", "))
+	}
+
+	if m, isMap := tryToConvertToGenericMap(value); isMap {
+		if len(m) == 0 {
+			return "{}"
+		}
+
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		lines := []string{"{"}
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("%s%s = %s", strings.Repeat("  ", indent+1), strconv.Quote(key), toHclLiteral(m[key], indent+1)))
+		}
+		lines = append(lines, strings.Repeat("  ", indent)+"}")
+		return strings.Join(lines, "\n")
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%v", value)
+	default:
+		return quoteHclString(fmt.Sprintf("%v", value))
+	}
+}
+
+// Quote the given string for HCL. Terraform treats ${ as the start of an interpolation, so that has to be escaped as
+// $${ to get a literal string.
+func quoteHclString(value string) string {
+	return strconv.Quote(strings.Replace(value, "${", "$${", -1))
+}
+
+// DuplicateRootModuleOutput is an error that occurs when two modules in a generated root module would re-export an
+// output with the same name.
+type DuplicateRootModuleOutput struct {
+	OutputName   string
+	FirstModule  string
+	SecondModule string
+}
+
+func (err DuplicateRootModuleOutput) Error() string {
+	return fmt.Sprintf("Modules %s and %s both export an output called %s. Set OutputPrefix or Outputs on one of them.", err.FirstModule, err.SecondModule, err.OutputName)
+}
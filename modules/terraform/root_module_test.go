@@ -0,0 +1,138 @@
+package terraform
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootModuleRender(t *testing.T) {
+	t.Parallel()
+
+	fixtureDir, err := filepath.Abs("../../test/fixtures/terraform-no-error")
+	assert.NoError(t, err)
+
+	rootModule := NewRootModule().
+		AddProvider("aws", map[string]interface{}{"region": "us-east-1"}).
+		AddModule("example", "../../test/fixtures/terraform-no-error", map[string]interface{}{
+			"name":           "foo-${bar}",
+			"instance_count": 3,
+			"enabled":        true,
+			"subnets":        []string{"a", "b"},
+			"tags":           map[string]string{"Name": "test"},
+			"vpc_id":         HclExpression("module.vpc.vpc_id"),
+			"no_items":       []string{},
+		})
+
+	expected := `provider "aws" {
+  region = "us-east-1"
+}
+
+module "example" {
+  source = "` + fixtureDir + `"
+  enabled = true
+  instance_count = 3
+  name = "foo-$${bar}"
+  no_items = []
+  subnets = ["a", "b"]
+  tags = {
+    "Name" = "test"
+  }
+  vpc_id = "${module.vpc.vpc_id}"
+}
+
+output "test" {
+  value = "${module.example.test}"
+}
+`
+
+	actual, err := rootModule.Render()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+// This test runs terraform validate on a rendered root module that passes every type of input to a module, to check
+// that the rendered HCL is valid, so it's skipped if the terraform binary isn't installed
+func TestRootModuleRenderIsValid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform is not installed")
+	}
+
+	rootModule := NewRootModule().
+		AddModule("first", "../../test/fixtures/terraform-no-error", nil).
+		AddModule("example", "../../test/fixtures/terraform-module-inputs", map[string]interface{}{
+			"name":           "foo-${bar}",
+			"instance_count": 3,
+			"enabled":        true,
+			"subnets":        []string{"a", "b"},
+			"tags":           map[string]string{"Name": "test"},
+			"vpc_id":         HclExpression("module.first.test"),
+			"no_items":       []string{},
+		})
+
+	options := &Options{
+		TerraformDir: WriteRootModuleToTemp(t, rootModule, t.Name()),
+		NoColor:      true,
+	}
+
+	Init(t, options)
+	RunTerraformCommand(t, options, "validate")
+}
+
+func TestRootModuleRenderExplicitOutputs(t *testing.T) {
+	t.Parallel()
+
+	rootModule := &RootModule{
+		Modules: []Module{
+			{Name: "vpc", Source: "git::git@github.com:foo/bar.git//vpc?ref=v0.0.1", Outputs: []string{"vpc_id"}, OutputPrefix: "vpc_"},
+			{Name: "consul", Source: "hashicorp/consul/aws", Version: "0.1.0"},
+		},
+	}
+
+	expected := `module "vpc" {
+  source = "git::git@github.com:foo/bar.git//vpc?ref=v0.0.1"
+}
+
+output "vpc_vpc_id" {
+  value = "${module.vpc.vpc_id}"
+}
+
+module "consul" {
+  source = "hashicorp/consul/aws"
+  version = "0.1.0"
+}
+`
+
+	actual, err := rootModule.Render()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestRootModuleRenderDuplicateOutputs(t *testing.T) {
+	t.Parallel()
+
+	rootModule := NewRootModule().
+		AddModule("first", "../../test/fixtures/terraform-no-error", nil).
+		AddModule("second", "../../test/fixtures/terraform-backend", nil)
+
+	_, err := rootModule.Render()
+	assert.Equal(t, DuplicateRootModuleOutput{OutputName: "test", FirstModule: "first", SecondModule: "second"}, err)
+}
+
+func TestWriteRootModuleToTemp(t *testing.T) {
+	t.Parallel()
+
+	rootModule := NewRootModule().AddModule("example", "../../test/fixtures/terraform-no-error", nil)
+
+	tmpDir := WriteRootModuleToTemp(t, rootModule, t.Name())
+
+	contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "main.tf"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), `module "example" {`)
+	assert.Contains(t, string(contents), `output "test" {`)
+}
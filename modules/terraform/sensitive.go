@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+// RegisterSensitiveVars registers the values of all the Vars listed in SensitiveVars as secrets, so they are masked
+// in all log output. Every Terraform command does this before it runs, so you only need to call it to mask the Vars in
+// something that's logged before then (e.g., saving the options with test_structure.SaveTerraformOptions). Only
+// strings can be masked, so log a warning that names every sensitive var with a value that isn't a string (or a list or
+// map of strings).
+func RegisterSensitiveVars(t *testing.T, options *Options) {
+	for _, name := range options.SensitiveVars {
+		if value, exists := options.Vars[name]; exists && !registerSensitiveValue(value) {
+			logger.LogfTo(t, options.Logger, logger.LevelWarn, "The value of sensitive var %s is not a string, so it is NOT masked in the log output", name)
+		}
+	}
+}
+
+// Register the given value as a secret. For lists and maps, every item is registered separately, as that's how they
+// may show up in log output. Only strings can be registered, so return false if the value is, or contains, anything
+// else, such as a number or a boolean.
+func registerSensitiveValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	masked := true
+	if slice, isSlice := tryToConvertToGenericSlice(value); isSlice {
+		for _, item := range slice {
+			masked = registerSensitiveValue(item) && masked
+		}
+	} else if m, isMap := tryToConvertToGenericMap(value); isMap {
+		for _, item := range m {
+			masked = registerSensitiveValue(item) && masked
+		}
+	} else if str, isString := value.(string); isString {
+		logger.RegisterSecret(str)
+	} else {
+		masked = false
+	}
+	return masked
+}
+
+// Register the values of all the outputs marked as sensitive as secrets, so they are masked in all log output. This
+// runs terraform output -json, without logging its output, once per apply, rather than on every call. If that fails,
+// log a warning and carry on, as reading a single output may still work.
+func registerSensitiveOutputs(t *testing.T, options *Options) {
+	if options.sensitiveOutputsRegistered {
+		return
+	}
+
+	if err := registerSensitiveOutputsE(t, options); err != nil {
+		logger.LogfTo(t, options.Logger, logger.LevelWarn, "Could not look up the sensitive outputs, so their values may not be masked in the logs: %v", err)
+		return
+	}
+	options.sensitiveOutputsRegistered = true
+}
+
+// Run terraform output -json, without logging its output, and register the values of all the outputs marked as
+// sensitive as secrets
+func registerSensitiveOutputsE(t *testing.T, options *Options) error {
+	out, err := runTerraformCommandE(t, options, true, "output", "-json")
+	if err != nil {
+		return err
+	}
+
+	values, err := parseSensitiveOutputValues(out)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !registerSensitiveValue(values[name]) {
+			logger.LogfTo(t, options.Logger, logger.LevelWarn, "The value of sensitive output %s is not a string, so it is NOT masked in the log output", name)
+		}
+	}
+	return nil
+}
+
+// The JSON format of a single output in terraform output -json
+type outputMeta struct {
+	Sensitive bool        `json:"sensitive"`
+	Value     interface{} `json:"value"`
+}
+
+// Parse the output of terraform output -json and return the values of all the outputs marked as sensitive, by name
+func parseSensitiveOutputValues(outputJSON string) (map[string]interface{}, error) {
+	outputs := map[string]outputMeta{}
+	if err := unmarshalTerraformJSON(outputJSON, &outputs); err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	for name, output := range outputs {
+		if output.Sensitive {
+			values[name] = output.Value
+		}
+	}
+	return values, nil
+}
+
+// InvalidOutputJSON is an error that occurs when the output of terraform output -json can't be parsed.
+type InvalidOutputJSON string
+
+func (output InvalidOutputJSON) Error() string {
+	return fmt.Sprintf("Output of terraform output -json does not contain a JSON object: %s", string(output))
+}
@@ -0,0 +1,132 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSensitiveOutputValues(t *testing.T) {
+	t.Parallel()
+
+	outputJSON := `
+Warning: some warning on stderr
+
+{
+    "db_password": {
+        "sensitive": true,
+        "type": "string",
+        "value": "hunter2"
+    },
+    "url": {
+        "sensitive": false,
+        "type": "string",
+        "value": "http://example.com"
+    }
+}`
+
+	values, err := parseSensitiveOutputValues(outputJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"db_password": "hunter2"}, values)
+
+	_, err = parseSensitiveOutputValues("The state file either has no outputs defined")
+	assert.Equal(t, InvalidOutputJSON("The state file either has no outputs defined"), err)
+}
+
+func TestRegisterSensitiveVars(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		Vars: map[string]interface{}{
+			"db_password": "test-register-sensitive-vars-password",
+			"api_keys":    []string{"test-register-sensitive-vars-key-1", "test-register-sensitive-vars-key-2"},
+			"name":        "test-register-sensitive-vars-name",
+		},
+		SensitiveVars: []string{"db_password", "api_keys"},
+	}
+
+	RegisterSensitiveVars(t, options)
+
+	args := FormatTerraformVarsAsArgs(options.Vars)
+	redacted := logger.Redact(fmt.Sprintf("%v", args))
+	assert.NotContains(t, redacted, "test-register-sensitive-vars-password")
+	assert.NotContains(t, redacted, "test-register-sensitive-vars-key-1")
+	assert.NotContains(t, redacted, "test-register-sensitive-vars-key-2")
+	assert.Contains(t, redacted, "test-register-sensitive-vars-name")
+}
+
+func TestRegisterSensitiveVarsWarnsAboutValuesThatAreNotMasked(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	options := &Options{
+		Vars: map[string]interface{}{
+			"short_password": "Tx9#q",
+			"port":           8443,
+			"keys":           []interface{}{"test-register-sensitive-vars-warn-key", true},
+		},
+		SensitiveVars: []string{"short_password", "port", "keys"},
+		Logger:        logger.NewWriterLogger(&buffer),
+	}
+
+	RegisterSensitiveVars(t, options)
+
+	// Every string is masked, however short, but there's no way to mask a number or a boolean
+	assert.Equal(t, "password "+logger.SecretMask, logger.Redact("password Tx9#q"))
+	assert.Equal(t, "key "+logger.SecretMask, logger.Redact("key test-register-sensitive-vars-warn-key"))
+	assert.NotContains(t, buffer.String(), "short_password")
+	assert.Contains(t, buffer.String(), "The value of sensitive var port is not a string")
+	assert.Contains(t, buffer.String(), "The value of sensitive var keys is not a string")
+}
+
+func TestOutputLooksUpSensitiveOutputsOncePerApply(t *testing.T) {
+	t.Parallel()
+
+	outputJSON := `{"db_password": {"sensitive": true, "type": "string", "value": "test-output-sensitive-password"}}`
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "terraform",
+		shell.StubResponse{Stdout: outputJSON},
+		shell.StubResponse{Stdout: "test-output-sensitive-password"},
+		shell.StubResponse{Stdout: "test-output-sensitive-password"},
+		shell.StubResponse{Stdout: "Apply complete!"},
+		shell.StubResponse{Stdout: outputJSON},
+		shell.StubResponse{Stdout: "test-output-sensitive-password"},
+	)
+
+	options := &Options{EnvVars: stubs.EnvVars()}
+
+	assert.Equal(t, "test-output-sensitive-password", Output(t, options, "db_password"))
+	assert.Equal(t, "test-output-sensitive-password", Output(t, options, "db_password"))
+	assert.Contains(t, logger.Redact("test-output-sensitive-password"), logger.SecretMask)
+
+	Apply(t, options)
+	Output(t, options, "db_password")
+
+	commands := []string{}
+	for _, invocation := range stubs.Invocations(t, "terraform") {
+		commands = append(commands, strings.Join(invocation.Args[:2], " "))
+	}
+	assert.Equal(t, []string{"output -json", "output -no-color", "output -no-color", "apply -input=false", "output -json", "output -no-color"}, commands)
+}
+
+func TestOutputWorksIfLookingUpSensitiveOutputsFails(t *testing.T) {
+	t.Parallel()
+
+	stubs := shell.NewStubs(t)
+	stubs.Add(t, "terraform",
+		shell.StubResponse{Stderr: "Error: output -json failed", ExitCode: 1},
+		shell.StubResponse{Stdout: "http://example.com"},
+	)
+
+	options := &Options{EnvVars: stubs.EnvVars()}
+
+	out, err := OutputE(t, options, "url")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com", out)
+}
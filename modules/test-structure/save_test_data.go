@@ -17,8 +17,10 @@ import (
 )
 
 // SaveTerraformOptions serializes and saves TerraformOptions into the given folder. This allows you to create TerraformOptions during setup
-// and to reuse that TerraformOptions later during validation and teardown.
+// and to reuse that TerraformOptions later during validation and teardown. The options are usually saved before any
+// Terraform command runs, so the SensitiveVars are registered as secrets first, to mask them in the log output.
 func SaveTerraformOptions(t *testing.T, testFolder string, terraformOptions *terraform.Options) {
+	terraform.RegisterSensitiveVars(t, terraformOptions)
 	SaveTestData(t, formatTerraformOptionsPath(testFolder), terraformOptions)
 }
 
@@ -127,7 +129,11 @@ func FormatTestDataPath(testFolder string, filename string) string {
 }
 
 // SaveTestData serializes and saves a value used at test time to the given path. This allows you to create some sort of test data
-// (e.g., TerraformOptions) during setup and to reuse this data later during validation and teardown.
+// (e.g., TerraformOptions) during setup and to reuse this data later during validation and teardown. Any secrets
+// registered with the logger package (e.g., the values of TerraformOptions.SensitiveVars) are masked in the log output,
+// including where the JSON encoding escapes them. They are NOT masked in the saved file: later stages load the file to
+// get the real values (e.g., to run terraform apply or destroy with the same Vars), which would break if the file held
+// masked values. Keep the test folder out of anything that gets published, such as CI artifacts.
 func SaveTestData(t *testing.T, path string, value interface{}) {
 	logger.Logf(t, "Storing test data in %s so it can be reused later", path)
 
@@ -140,7 +146,7 @@ func SaveTestData(t *testing.T, path string, value interface{}) {
 		t.Fatalf("Failed to convert value %s to JSON: %v", path, err)
 	}
 
-	t.Logf("Marshalled JSON: %s", string(bytes))
+	t.Logf("Marshalled JSON: %s", logger.Redact(string(bytes)))
 
 	parentDir := filepath.Dir(path)
 	if err := os.MkdirAll(parentDir, 0777); err != nil {
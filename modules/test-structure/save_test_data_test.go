@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/packer"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 )
@@ -109,6 +111,45 @@ func TestSaveAndLoadTerraformOptions(t *testing.T) {
 	assert.Equal(t, expectedData, actualData)
 }
 
+func TestSaveTerraformOptionsMasksSensitiveVars(t *testing.T) {
+	t.Parallel()
+
+	tmpFolder, err := ioutil.TempDir("", "save-terraform-options-sensitive-vars")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	// Save the options before running any Terraform command, as most tests do
+	secret := "test-save-terraform-options-password"
+	options := &terraform.Options{
+		TerraformDir:  "/abc/def/ghi",
+		Vars:          map[string]interface{}{"db_password": secret},
+		SensitiveVars: []string{"db_password"},
+	}
+	SaveTerraformOptions(t, tmpFolder, options)
+
+	assert.Equal(t, `{"db_password":"`+logger.SecretMask+`"}`, logger.Redact(`{"db_password":"`+secret+`"}`))
+	assert.Equal(t, options, LoadTerraformOptions(t, tmpFolder))
+}
+
+func TestSaveAndLoadOptionsWithLogger(t *testing.T) {
+	t.Parallel()
+
+	tmpFolder, err := ioutil.TempDir("", "save-and-load-options-with-logger")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	// The Logger isn't saved, as an interface can't be loaded back, so the loaded options use the default Logger
+	terraformOptions := &terraform.Options{TerraformDir: "/abc/def/ghi", Logger: logger.Discard}
+	SaveTerraformOptions(t, tmpFolder, terraformOptions)
+	assert.Equal(t, &terraform.Options{TerraformDir: "/abc/def/ghi"}, LoadTerraformOptions(t, tmpFolder))
+
+	packerOptions := &packer.Options{Template: "/abc/def/build.json", Logger: logger.Discard}
+	SavePackerOptions(t, tmpFolder, packerOptions)
+	assert.Equal(t, &packer.Options{Template: "/abc/def/build.json"}, LoadPackerOptions(t, tmpFolder))
+}
+
 func TestSaveAndLoadAmiId(t *testing.T) {
 	t.Parallel()
 
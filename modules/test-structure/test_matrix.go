@@ -0,0 +1,167 @@
+package test_structure
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+// TestMatrix describes the combinations a Terraform test should run against. RunTestMatrix runs one parallel subtest
+// for every combination of region, var set, and Terraform version. An empty dimension is ignored, so a matrix with
+// only Regions runs one subtest per region.
+type TestMatrix struct {
+	Regions                 []string // The regions to run the test in
+	VarSets                 []VarSet // The sets of Terraform variables to run the test with
+	TerraformVersions       []string // The Terraform versions to run the test with. The test should point TerraformOptions.TerraformBinary at the binary for MatrixCase.TerraformVersion.
+	MaxConcurrencyPerRegion int      // The maximum number of subtests to run in the same region at the same time. Zero means no limit.
+}
+
+// VarSet is a named set of Terraform variables in a TestMatrix.
+type VarSet struct {
+	Name string
+	Vars map[string]interface{}
+}
+
+// MatrixCase is a single combination from a TestMatrix that is passed to the test function in RunTestMatrix.
+type MatrixCase struct {
+	Name             string // The name of the subtest for this case
+	Region           string // The region for this case, or empty if the matrix has no Regions
+	VarSet           VarSet // The var set for this case, or an empty VarSet if the matrix has no VarSets
+	TerraformVersion string // The Terraform version for this case, or empty if the matrix has no TerraformVersions
+	UniqueID         string // A unique ID for this case that can be used to namespace resources
+	TerraformDir     string // The path to this case's own copy of the Terraform code
+}
+
+// MatrixCaseResult is the outcome of running a single MatrixCase.
+type MatrixCaseResult struct {
+	Case     MatrixCase
+	Result   string // PASS, FAIL, or SKIP
+	Duration time.Duration
+}
+
+// RunTestMatrix runs the given test function once for every combination in the given matrix, each in its own parallel
+// subtest. Every subtest gets a unique ID and its own temp copy of rootFolder, so the subtests can't overwrite each
+// other's .terraform folder or state; MatrixCase.TerraformDir is the path to terraformFolder within that copy. Unlike
+// CopyTerraformFolderToTemp, the copy is made even if a SKIP_XXX environment variable is set, as the subtests run in
+// parallel. Once all the subtests are done, this method logs a summary table and returns the result of every case.
+func RunTestMatrix(t *testing.T, rootFolder string, terraformFolder string, matrix TestMatrix, test func(t *testing.T, matrixCase MatrixCase)) []MatrixCaseResult {
+	cases := matrix.cases()
+	regionLimits := map[string]chan bool{}
+	if matrix.MaxConcurrencyPerRegion > 0 {
+		for _, matrixCase := range cases {
+			regionLimits[matrixCase.Region] = make(chan bool, matrix.MaxConcurrencyPerRegion)
+		}
+	}
+
+	results := make([]MatrixCaseResult, len(cases))
+	var resultsMutex sync.Mutex
+
+	// t.Run only returns once all the parallel subtests within it have completed, which is what allows us to print
+	// the summary below
+	t.Run("matrix", func(t *testing.T) {
+		for i, matrixCase := range cases {
+			// Capture the range variables, as the subtests run after the loop has moved on
+			i, matrixCase := i, matrixCase
+
+			t.Run(matrixCase.Name, func(t *testing.T) {
+				t.Parallel()
+
+				if limit, hasLimit := regionLimits[matrixCase.Region]; hasLimit {
+					limit <- true
+					defer func() { <-limit }()
+				}
+
+				start := time.Now()
+				defer func() {
+					resultsMutex.Lock()
+					defer resultsMutex.Unlock()
+					results[i] = MatrixCaseResult{Case: matrixCase, Result: testResult(t), Duration: time.Since(start)}
+				}()
+
+				tmpRootFolder, err := files.CopyTerraformFolderToTemp(rootFolder, cleanName(t.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				matrixCase.TerraformDir = filepath.Join(tmpRootFolder, terraformFolder)
+				matrixCase.UniqueID = random.UniqueId()
+
+				logger.Logf(t, "Running test matrix case %s with unique ID %s in %s", matrixCase.Name, matrixCase.UniqueID, matrixCase.TerraformDir)
+				test(t, matrixCase)
+			})
+		}
+	})
+
+	logger.Logf(t, "Test matrix summary:\n%s", formatMatrixSummary(results))
+	return results
+}
+
+// Expand the matrix into the list of all its combinations
+func (matrix TestMatrix) cases() []MatrixCase {
+	regions := matrix.Regions
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	varSets := matrix.VarSets
+	if len(varSets) == 0 {
+		varSets = []VarSet{{}}
+	}
+	versions := matrix.TerraformVersions
+	if len(versions) == 0 {
+		versions = []string{""}
+	}
+
+	cases := []MatrixCase{}
+	for _, region := range regions {
+		for _, varSet := range varSets {
+			for _, version := range versions {
+				nameParts := []string{}
+				for _, part := range []string{region, varSet.Name, version} {
+					if part != "" {
+						nameParts = append(nameParts, part)
+					}
+				}
+
+				cases = append(cases, MatrixCase{
+					Name:             strings.Join(nameParts, "_"),
+					Region:           region,
+					VarSet:           varSet,
+					TerraformVersion: version,
+				})
+			}
+		}
+	}
+	return cases
+}
+
+func testResult(t *testing.T) string {
+	if t.Failed() {
+		return "FAIL"
+	}
+	if t.Skipped() {
+		return "SKIP"
+	}
+	return "PASS"
+}
+
+// Format the given results as a table with one row per case
+func formatMatrixSummary(results []MatrixCaseResult) string {
+	var out bytes.Buffer
+	writer := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "CASE\tREGION\tVARS\tTERRAFORM\tRESULT\tDURATION")
+	for _, result := range results {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Case.Name, result.Case.Region, result.Case.VarSet.Name, result.Case.TerraformVersion, result.Result, result.Duration.Round(time.Second))
+	}
+	writer.Flush()
+
+	return out.String()
+}
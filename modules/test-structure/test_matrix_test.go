@@ -0,0 +1,58 @@
+package test_structure
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTestMatrix(t *testing.T) {
+	t.Parallel()
+
+	matrix := TestMatrix{
+		Regions: []string{"us-east-1", "eu-west-1"},
+		VarSets: []VarSet{
+			{Name: "small", Vars: map[string]interface{}{"instance_type": "t2.micro"}},
+			{Name: "large", Vars: map[string]interface{}{"instance_type": "m4.large"}},
+		},
+		MaxConcurrencyPerRegion: 1,
+	}
+
+	var mutex sync.Mutex
+	uniqueIDs := map[string]bool{}
+	terraformDirs := map[string]bool{}
+
+	results := RunTestMatrix(t, "../../test/fixtures", "terraform-no-error", matrix, func(t *testing.T, matrixCase MatrixCase) {
+		assert.True(t, files.FileExists(filepath.Join(matrixCase.TerraformDir, "main.tf")))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		uniqueIDs[matrixCase.UniqueID] = true
+		terraformDirs[matrixCase.TerraformDir] = true
+	})
+
+	assert.Len(t, results, 4)
+	assert.Len(t, uniqueIDs, 4)
+	assert.Len(t, terraformDirs, 4)
+
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.Case.Name)
+		assert.Equal(t, "PASS", result.Result)
+	}
+	assert.Equal(t, []string{"us-east-1_small", "us-east-1_large", "eu-west-1_small", "eu-west-1_large"}, names)
+}
+
+func TestTestMatrixCasesSkipsEmptyDimensions(t *testing.T) {
+	t.Parallel()
+
+	cases := TestMatrix{TerraformVersions: []string{"0.11.7", "0.11.8"}}.cases()
+
+	assert.Len(t, cases, 2)
+	assert.Equal(t, "0.11.7", cases[0].Name)
+	assert.Equal(t, "0.11.8", cases[1].TerraformVersion)
+	assert.Equal(t, "", cases[1].Region)
+}
@@ -0,0 +1,17 @@
+variable "name" {}
+
+variable "instance_count" {}
+
+variable "enabled" {}
+
+variable "subnets" {}
+
+variable "tags" {}
+
+variable "vpc_id" {}
+
+variable "no_items" {}
+
+output "summary" {
+  value = "${var.name} in ${var.vpc_id}"
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/gruntwork-io/terratest/modules/http-helper"
 	"github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/random"
-	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/gruntwork-io/terratest/modules/test-structure"
 )
@@ -128,17 +127,18 @@ func validateAsgRedeploy(t *testing.T, workingDir string) {
 	url := terraform.Output(t, terraformOptions, "url")
 
 	// Check once per second that the ELB returns a proper response to make sure there is no downtime during deployment
-	elbChecks := retry.DoInBackgroundUntilStopped(t, fmt.Sprintf("Check URL %s", url), 1*time.Second, func() {
-		http_helper.HttpGetWithCustomValidation(t, url, func(statusCode int, body string) bool {
-			return statusCode == 200 && (body == originalText || body == newText)
-		})
-	})
+	elbChecks := http_helper.ContinuouslyCheck(t, url, func(statusCode int, body string) bool {
+		return statusCode == 200 && (body == originalText || body == newText)
+	}, 1*time.Second)
 
 	// Redeploy the cluster
 	terraform.Apply(t, terraformOptions)
 
-	// Stop checking the ELB
-	elbChecks.Done()
+	// Stop checking the ELB and make sure every check passed
+	report := elbChecks.Stop()
+	if report.Failures > 0 {
+		t.Fatalf("Expected zero downtime during the redeploy, but %d of %d checks failed in %d error windows", report.Failures, report.Total, len(report.ErrorWindows))
+	}
 }
 
 // Fetch the most recent syslogs for the instances in the ASG. This is a handy way to see what happened on each